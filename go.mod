@@ -5,3 +5,7 @@ go 1.23.2
 require github.com/google/uuid v1.6.0
 
 require github.com/lib/pq v1.10.9
+
+require golang.org/x/net v0.33.0
+
+require golang.org/x/text v0.21.0 // indirect
@@ -4,18 +4,30 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"net/url"
 	"os"
 	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/lib/pq"
 	"github.com/seanhuebl/blog_aggregator/internal/database"
+	"github.com/seanhuebl/blog_aggregator/internal/opml"
+	"github.com/seanhuebl/blog_aggregator/internal/render"
 	"github.com/seanhuebl/blog_aggregator/internal/rss"
 )
 
+// defaultScrapeConcurrency is the number of feeds agg scrapes in parallel when no concurrency is given.
+const defaultScrapeConcurrency = 1
+
+// displayWidth is the line length post bodies are wrapped to when displayed.
+const displayWidth = 80
+
 // configFileName defines the location of the configuration file within the user's home directory.
 const configFileName = "/.gatorconfig.json"
 
@@ -23,6 +35,50 @@ const configFileName = "/.gatorconfig.json"
 type Config struct {
 	DbUrl           string `json:"db_url"`            // Database connection URL
 	CurrentUserName string `json:"current_user_name"` // The currently logged-in user's username
+
+	MaxFeedBytes int64 `json:"max_feed_bytes,omitempty"` // Largest feed to download in bytes; 0 keeps the default
+	MaxFeedItems int   `json:"max_feed_items,omitempty"` // Most items to read from a feed; 0 keeps the default
+
+	FetchTimeout string                       `json:"fetch_timeout,omitempty"` // How long fetching a feed may take, such as "30s"; empty keeps the default
+	ProxyURL     string                       `json:"proxy_url,omitempty"`     // Proxy to fetch feeds through; empty uses the proxy environment variables
+	CABundle     string                       `json:"ca_bundle,omitempty"`     // Path to a PEM file of extra certificate authorities to trust
+	MaxRedirects int                          `json:"max_redirects,omitempty"` // Redirects followed before a fetch fails; 0 keeps the default
+	UserAgent    string                       `json:"user_agent,omitempty"`    // Product name sent as the User-Agent; empty keeps "gator"
+	ContactURL   string                       `json:"contact_url,omitempty"`   // URL where publishers can reach you, added to the User-Agent
+	FeedHeaders  map[string]map[string]string `json:"feed_headers,omitempty"`  // Extra headers to send, keyed by feed URL
+
+	CredentialsKey string `json:"credentials_key,omitempty"` // Base64-encoded 32-byte key that feed credentials are encrypted with
+}
+
+// NewFetcher creates the feed fetcher described by the configuration.
+//
+// Returns:
+// - A pointer to the fetcher.
+// - An error if a setting is invalid, such as a malformed timeout or proxy URL.
+func (c *Config) NewFetcher() (*rss.Fetcher, error) {
+	var timeout time.Duration
+	if c.FetchTimeout != "" {
+		var err error
+		timeout, err = time.ParseDuration(c.FetchTimeout)
+		if err != nil || timeout <= 0 {
+			return nil, fmt.Errorf("invalid fetch_timeout %q", c.FetchTimeout)
+		}
+	}
+	fetcher, err := rss.NewFetcher(rss.FetcherConfig{
+		Timeout:      timeout,
+		ProxyURL:     c.ProxyURL,
+		CABundle:     c.CABundle,
+		MaxRedirects: c.MaxRedirects,
+		UserAgent:    c.UserAgent,
+		ContactURL:   c.ContactURL,
+		Headers:      c.FeedHeaders,
+		MaxBytes:     c.MaxFeedBytes,
+		MaxItems:     c.MaxFeedItems,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("invalid fetch settings: %v", err)
+	}
+	return fetcher, nil
 }
 
 // SetUser updates the current user in the configuration file.
@@ -39,12 +95,18 @@ func (c *Config) SetUser(username string) error {
 	if err != nil {
 		return fmt.Errorf("error marshalling to JSON: %v", err)
 	}
-	// Write the updated configuration to the file.
+	// Write the updated configuration to the file, readable only by its owner since it holds
+	// the database URL and the credentials key.
 	filepath := getConfigFilePath()
-	err = os.WriteFile(filepath, jdata, 0644)
+	err = os.WriteFile(filepath, jdata, 0600)
 	if err != nil {
 		return fmt.Errorf("error writing: %v", err)
 	}
+	// WriteFile keeps the permissions of an existing file, so tighten them as well.
+	err = os.Chmod(filepath, 0600)
+	if err != nil {
+		return fmt.Errorf("error setting permissions: %v", err)
+	}
 	return nil
 }
 
@@ -93,7 +155,9 @@ type Command struct {
 // State holds the application state, including the database and configuration.
 type State struct {
 	Db        *database.Queries // A pointer to the database queries interface
+	Conn      *sql.DB           // The database connection, for queries that run in a transaction
 	ConfigPtr *Config           // A pointer to the application's configuration
+	Fetcher   *rss.Fetcher      // The fetcher used to download feeds
 }
 
 // MiddlewareLoggedIn ensures that a user is logged in before executing a command.
@@ -222,60 +286,183 @@ func HandlerReset(s *State, cmd Command) error {
 }
 
 // HandlerAgg periodically scrapes feeds based on a provided interval.
+// On every tick a batch of feeds is claimed and scraped by a pool of workers.
 //
 // Parameters:
 // - s: The current application state.
-// - cmd: The command containing the time interval as an argument.
+// - cmd: The command containing the time interval, and optionally the concurrency and batch size, as arguments.
 //
 // Returns:
-// - An error if the interval is invalid or the scraping fails.
+// - An error if the arguments are invalid or the scraping fails.
 func HandlerAgg(s *State, cmd Command) error {
-	if len(cmd.Arguments) != 1 {
-		return fmt.Errorf("agg takes one argument")
+	if len(cmd.Arguments) < 1 || len(cmd.Arguments) > 3 {
+		return fmt.Errorf("agg takes one to three arguments: interval [concurrency] [batch_size]")
 	}
 	timeBetweenReqs, err := time.ParseDuration(cmd.Arguments[0])
 	if err != nil {
 		return fmt.Errorf("error parsing time duration: %v", err)
 	}
-	fmt.Printf("Collecting feeds every %v\n", cmd.Arguments[0])
+
+	// Parse the optional worker pool settings.
+	concurrency := defaultScrapeConcurrency
+	if len(cmd.Arguments) > 1 {
+		concurrency, err = strconv.Atoi(cmd.Arguments[1])
+		if err != nil || concurrency < 1 {
+			return fmt.Errorf("concurrency must be a positive integer")
+		}
+	}
+	batchSize := concurrency // By default, claim one feed per worker on each tick.
+	if len(cmd.Arguments) > 2 {
+		batchSize, err = strconv.Atoi(cmd.Arguments[2])
+		if err != nil || batchSize < 1 {
+			return fmt.Errorf("batch size must be a positive integer")
+		}
+	}
+
+	fmt.Printf("Collecting up to %v feeds every %v with %v workers\n", batchSize, cmd.Arguments[0], concurrency)
 	ticker := time.NewTicker(timeBetweenReqs)
 	for ; ; <-ticker.C {
-		ScrapeFeeds(s)
+		// Report failures without stopping, as each feed is retried on its own schedule.
+		if err := ScrapeFeeds(s, concurrency, batchSize); err != nil {
+			fmt.Println(err)
+		}
 	}
 }
 
 // HandlerAddFeed adds a new feed and subscribes the current user to it.
+// If the URL points to a web page, the feed it advertises is added instead.
+// Private feeds take --user and --password for HTTP Basic auth, or --token for a bearer
+// token; the credentials are stored encrypted and only ever sent to the feed's host.
 //
 // Parameters:
 // - s: The current application state.
-// - cmd: The command containing the feed name and URL as arguments.
+// - cmd: The command containing the feed name and URL (of the feed or its website) as arguments, and optional credentials.
 // - user: The currently logged-in user.
 //
 // Returns:
-// - An error if the feed cannot be added or followed.
+// - An error if the arguments are invalid, no single feed can be found, or the feed cannot be added or followed.
 func HandlerAddFeed(s *State, cmd Command, user database.User) error {
-	if len(cmd.Arguments) != 2 {
+	// Separate the name and URL from the credential flags.
+	var positional []string
+	var secret credentialSecret
+	var passwordSet bool
+	for i := 0; i < len(cmd.Arguments); i++ {
+		arg := cmd.Arguments[i]
+		switch arg {
+		case "--user", "--password", "--token":
+			if i+1 >= len(cmd.Arguments) {
+				return fmt.Errorf("%v requires a value", arg)
+			}
+			i++
+			switch arg {
+			case "--user":
+				secret.Username = cmd.Arguments[i]
+			case "--password":
+				secret.Password = cmd.Arguments[i]
+				passwordSet = true
+			default:
+				secret.Token = cmd.Arguments[i]
+			}
+		default:
+			if strings.HasPrefix(arg, "--") {
+				return fmt.Errorf("unknown addfeed option: %v", arg)
+			}
+			positional = append(positional, arg)
+		}
+	}
+	if len(positional) != 2 {
 		return fmt.Errorf("addfeed takes exactly two arguments")
 	}
+	hasCredentials := secret.Username != "" || passwordSet || secret.Token != ""
+	switch {
+	case secret.Token != "" && (secret.Username != "" || passwordSet):
+		return fmt.Errorf("--token cannot be combined with --user or --password")
+	case passwordSet && secret.Username == "":
+		return fmt.Errorf("--password requires --user")
+	}
+
+	// Authenticate while looking for the feed, so private feeds can be found.
+	// The credentials belong to the host the user typed, and are never sent anywhere else.
+	ctx := context.Background()
+	var credentialHost string
+	if hasCredentials {
+		if _, err := s.ConfigPtr.credentialsCipher(); err != nil {
+			return err
+		}
+		parsed, err := url.Parse(positional[1])
+		if err != nil || parsed.Host == "" {
+			return fmt.Errorf("invalid feed URL: %v", positional[1])
+		}
+		if parsed.Scheme != "https" {
+			return fmt.Errorf("credentials can only be used with https URLs, so they are not sent in cleartext")
+		}
+		credentialHost = strings.ToLower(parsed.Host)
+		ctx = rss.WithCredentials(ctx, rss.Credentials{
+			Host: credentialHost, Username: secret.Username, Password: secret.Password, Token: secret.Token,
+		})
+	}
+
+	// Resolve the URL to a feed, in case it points to the site's home page.
+	candidates, err := s.Fetcher.DiscoverFeeds(ctx, positional[1])
+	if err != nil {
+		return fmt.Errorf("unable to find feed: %v", err)
+	}
+	if len(candidates) > 1 {
+		fmt.Printf("Found %v feeds at %v:\n", len(candidates), positional[1])
+		for _, candidate := range candidates {
+			fmt.Printf("  %v\n", candidate)
+		}
+		return fmt.Errorf("multiple feeds found: run addfeed again with one of the URLs above")
+	}
+	feedURL := candidates[0]
+	if feedURL != positional[1] {
+		fmt.Printf("Discovered feed: %v\n", feedURL)
+	}
+	if hasCredentials {
+		// Refuse to hand the credentials to a feed hosted elsewhere, such as one a page links to.
+		parsed, err := url.Parse(feedURL)
+		if err != nil || parsed.Scheme != "https" || !strings.EqualFold(parsed.Host, credentialHost) {
+			return fmt.Errorf("the feed %v is not on https://%v, so the credentials cannot be used with it", feedURL, credentialHost)
+		}
+	}
+	// Add the feed, its credentials and the follow together, so a failure leaves nothing behind.
+	tx, err := s.Conn.BeginTx(context.Background(), nil)
+	if err != nil {
+		return fmt.Errorf("unable to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+	qtx := s.Db.WithTx(tx)
+
 	feedID := uuid.New()
-	feed, err := s.Db.AddFeed(context.Background(), database.AddFeedParams{
-		ID: feedID, Name: cmd.Arguments[0], Url: cmd.Arguments[1], UserID: user.ID,
+	feed, err := qtx.AddFeed(context.Background(), database.AddFeedParams{
+		ID: feedID, Name: positional[0], Url: feedURL, UserID: user.ID,
 	})
 	if err != nil {
 		return fmt.Errorf("unable to add feed: %v", err)
 	}
+	if hasCredentials {
+		err = storeFeedCredentials(s, qtx, feed.ID, credentialHost, secret)
+		if err != nil {
+			return err
+		}
+	}
 	followID := uuid.New()
-	_, err = s.Db.CreateFeedFollow(context.Background(), database.CreateFeedFollowParams{
+	_, err = qtx.CreateFeedFollow(context.Background(), database.CreateFeedFollowParams{
 		ID: followID, UserID: user.ID, FeedID: feed.ID,
 	})
 	if err != nil {
 		return fmt.Errorf("unable to follow feed: %v", err)
 	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("unable to commit transaction: %v", err)
+	}
 	fmt.Println(feed)
 	return nil
 }
 
-// HandlerFeeds retrieves and prints all feeds in the database.
+// HandlerFeeds retrieves and prints all feeds in the database, along with the metadata
+// each feed publishes about itself. The name given to a feed when it was added is shown
+// in place of the feed's own title.
 //
 // Parameters:
 // - s: The current application state.
@@ -292,7 +479,16 @@ func HandlerFeeds(s *State, cmd Command) error {
 		return fmt.Errorf("unable to get feeds: %v", err)
 	}
 	for _, feed := range feeds {
-		fmt.Printf("%v\n", feed)
+		fmt.Printf("%v (%v)\n", render.StripControl(feed.Name), render.StripControl(feed.Url))
+		if feed.SiteTitle.String != feed.Name {
+			printFeedField("Title", feed.SiteTitle)
+		}
+		printFeedField("Website", feed.SiteUrl)
+		printFeedField("Description", feed.SiteDescription)
+		printFeedField("Language", feed.Language)
+		printFeedField("Image", feed.ImageUrl)
+		printFeedField("Generator", feed.Generator)
+		fmt.Printf("  Added by: %v\n", feed.Name_2)
 	}
 	return nil
 }
@@ -353,7 +549,8 @@ func HandlerUnfollow(s *State, cmd Command, user database.User) error {
 	return nil
 }
 
-// HandlerFollowing lists all feeds that the current user is following.
+// HandlerFollowing lists all feeds that the current user is following, along with the
+// metadata each feed publishes about itself and a notice for feeds that are no longer fetched.
 //
 // Parameters:
 // - s: The current application state.
@@ -371,41 +568,89 @@ func HandlerFollowing(s *State, cmd Command, user database.User) error {
 	if err != nil {
 		return fmt.Errorf("unable to get user's feeds: %v", err)
 	}
-	// Print each feed's name.
+	// Print each feed's name and metadata.
 	for _, feed := range feedsFollowed {
-		fmt.Println(feed.FeedName)
+		fmt.Println(render.StripControl(feed.FeedName))
+		if feed.SiteTitle.String != feed.FeedName {
+			printFeedField("Title", feed.SiteTitle)
+		}
+		printFeedField("Website", feed.SiteUrl)
+		printFeedField("Description", feed.SiteDescription)
+		printFeedField("Language", feed.Language)
+		switch feed.Status {
+		case database.FeedStatusDisabled:
+			fmt.Printf("  NOTICE: this feed is disabled and no longer fetched (%v). Run `gator enable-feed %v` to resume it.\n", render.StripControl(feed.DisabledReason.String), render.StripControl(feed.Url))
+		case database.FeedStatusDegraded:
+			fmt.Printf("  NOTICE: this feed has failed %v times in a row and will be disabled if it keeps failing.\n", feed.ConsecutiveFailures)
+		}
 	}
 	return nil
 }
 
+// printFeedField prints an indented line of feed metadata if the value is known.
+//
+// Parameters:
+// - label: The name of the field.
+// - value: The value of the field.
+func printFeedField(label string, value sql.NullString) {
+	// Feeds often describe themselves in HTML, so render it as a single line of text.
+	if text := strings.Join(strings.Fields(render.Text(value.String, 0)), " "); text != "" {
+		fmt.Printf("  %v: %v\n", label, text)
+	}
+}
+
 // HandlerBrowse retrieves and displays posts from feeds that the current user follows.
+// Posts are shown with their summary unless --full is given, in which case their full
+// content is shown. Either one stands in for the other when a post only has one of them.
+// Bodies are rendered from HTML into wrapped plain text, with links listed as footnotes.
+// --author and --category narrow the posts down to those by an author or filed under a category.
 //
 // Parameters:
 // - s: The current application state.
-// - cmd: The command containing an optional limit argument.
+// - cmd: The command containing an optional limit, --full flag and --author and --category filters.
 // - user: The currently logged-in user.
 //
 // Returns:
-// - An error if posts cannot be retrieved or if the limit argument is invalid.
+// - An error if posts cannot be retrieved or if the arguments are invalid.
 func HandlerBrowse(s *State, cmd Command, user database.User) error {
-	var limit int
-	var err error
+	limit := 2 // Default limit if no argument is provided.
+	full := false
+	limitSet := false
+	var author, category sql.NullString
 
-	// Validate the number of arguments and parse the limit if provided.
-	if len(cmd.Arguments) > 1 {
-		return fmt.Errorf("browse takes up to one argument")
-	} else if len(cmd.Arguments) == 1 {
-		limit, err = strconv.Atoi(cmd.Arguments[0])
-		if err != nil {
-			return fmt.Errorf("post-browse argument must be an integer: %v", err)
+	// Parse the flags and the limit if provided.
+	for i := 0; i < len(cmd.Arguments); i++ {
+		arg := cmd.Arguments[i]
+		switch {
+		case arg == "--full":
+			full = true
+		case arg == "--author" || arg == "--category":
+			if i+1 >= len(cmd.Arguments) || strings.TrimSpace(cmd.Arguments[i+1]) == "" {
+				return fmt.Errorf("%v requires a value", arg)
+			}
+			i++
+			if arg == "--author" {
+				author = parseToNullString(strings.TrimSpace(cmd.Arguments[i]))
+			} else {
+				category = parseToNullString(strings.TrimSpace(cmd.Arguments[i]))
+			}
+		case strings.HasPrefix(arg, "--"):
+			return fmt.Errorf("unknown browse option: %v", arg)
+		case limitSet:
+			return fmt.Errorf("browse takes up to one limit argument")
+		default:
+			var err error
+			limit, err = strconv.Atoi(arg)
+			if err != nil {
+				return fmt.Errorf("post-browse argument must be an integer: %v", err)
+			}
+			limitSet = true
 		}
-	} else {
-		limit = 2 // Default limit if no argument is provided.
 	}
 
 	// Retrieve posts from the user's followed feeds with the specified limit.
 	posts, err := s.Db.GetPostsForUser(context.Background(), database.GetPostsForUserParams{
-		UserID: user.ID, Limit: int32(limit),
+		UserID: user.ID, Author: author, Category: category, Limit: int32(limit),
 	})
 	if err != nil {
 		return fmt.Errorf("error getting posts: %v", err)
@@ -413,82 +658,391 @@ func HandlerBrowse(s *State, cmd Command, user database.User) error {
 
 	// Display the retrieved posts.
 	for _, post := range posts {
-		fmt.Printf("Title:\n%v\n\nURL:\n%v\n\n", post.Title.String, post.Url.String)
-		fmt.Printf("Content:\n%v\n\n", post.Description.String)
-		fmt.Printf("Published on:\n%v\n\n", post.PublishedAt.Time)
+		body := post.Description.String
+		if (full && post.Content.String != "") || body == "" {
+			body = post.Content.String
+		}
+		fmt.Printf("Title:\n%v\n\nURL:\n%v\n\n", render.StripControl(post.Title.String), render.StripControl(post.Url.String))
+		if len(post.Authors) > 0 {
+			fmt.Printf("Authors:\n%v\n\n", render.StripControl(strings.Join(post.Authors, ", ")))
+		}
+		if len(post.Categories) > 0 {
+			fmt.Printf("Categories:\n%v\n\n", render.StripControl(strings.Join(post.Categories, ", ")))
+		}
+		fmt.Printf("Content:\n%v\n\n", render.Text(body, displayWidth))
+		fmt.Printf("Published on:\n%v%v\n\n", post.PublishedAt.Time, inferredNote(post.PublishedAtInferred))
+	}
+	return nil
+}
+
+// HandlerImportOPML subscribes the current user to every feed listed in an OPML file.
+// Feeds that are not yet known are created; existing feeds are reused.
+//
+// Parameters:
+// - s: The current application state.
+// - cmd: The command containing the path to the OPML file as an argument.
+// - user: The currently logged-in user.
+//
+// Returns:
+// - An error if the file cannot be read or parsed. Failures for individual feeds are reported and counted.
+func HandlerImportOPML(s *State, cmd Command, user database.User) error {
+	if len(cmd.Arguments) != 1 {
+		return fmt.Errorf("import-opml takes one argument: file")
+	}
+	file, err := os.Open(cmd.Arguments[0])
+	if err != nil {
+		return fmt.Errorf("unable to open OPML file: %v", err)
+	}
+	defer file.Close()
+
+	doc, err := opml.Parse(file)
+	if err != nil {
+		return fmt.Errorf("unable to parse OPML file: %v", err)
+	}
+
+	var created, skipped, failed int
+	for _, sub := range doc.Subscriptions() {
+		isNew, err := importSubscription(s, user, sub)
+		if err != nil {
+			fmt.Printf("failed to import %v: %v\n", sub.XMLURL, err)
+			failed++
+		} else if isNew {
+			created++
+		} else {
+			skipped++
+		}
+	}
+	fmt.Printf("Imported OPML: %v created, %v skipped, %v failed\n", created, skipped, failed)
+	return nil
+}
+
+// importSubscription makes sure a feed from an OPML file exists and that the user follows it.
+//
+// Parameters:
+// - s: The current application state.
+// - user: The user importing the subscription.
+// - sub: The subscription read from the OPML file.
+//
+// Returns:
+// - true if the feed was created, false if an existing feed was reused.
+// - An error if the feed cannot be looked up, created or followed.
+func importSubscription(s *State, user database.User, sub opml.Subscription) (bool, error) {
+	// Reuse the feed if another user (or an earlier outline) already added it.
+	var feedID uuid.UUID
+	isNew := false
+	existing, err := s.Db.GetFeed(context.Background(), sub.XMLURL)
+	switch {
+	case err == nil:
+		feedID = existing.ID
+	case errors.Is(err, sql.ErrNoRows):
+		name := sub.Title
+		if name == "" {
+			name = sub.XMLURL
+		}
+		feed, err := s.Db.AddFeed(context.Background(), database.AddFeedParams{
+			ID: uuid.New(), Name: name, Url: sub.XMLURL, UserID: user.ID,
+		})
+		if err != nil {
+			return false, fmt.Errorf("unable to add feed: %v", err)
+		}
+		feedID = feed.ID
+		isNew = true
+	default:
+		return false, fmt.Errorf("unable to get feed: %v", err)
+	}
+
+	// Follow the feed, ignoring follows that already exist.
+	_, err = s.Db.CreateFeedFollow(context.Background(), database.CreateFeedFollowParams{
+		ID: uuid.New(), UserID: user.ID, FeedID: feedID,
+	})
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			return isNew, nil
+		}
+		return false, fmt.Errorf("unable to follow feed: %v", err)
+	}
+	return isNew, nil
+}
+
+// HandlerExportOPML writes the current user's subscriptions as an OPML document,
+// either to the given file or to standard output.
+//
+// Parameters:
+// - s: The current application state.
+// - cmd: The command containing an optional output file path.
+// - user: The currently logged-in user.
+//
+// Returns:
+// - An error if the subscriptions cannot be retrieved or the document cannot be written.
+func HandlerExportOPML(s *State, cmd Command, user database.User) error {
+	if len(cmd.Arguments) > 1 {
+		return fmt.Errorf("export-opml takes up to one argument: file")
+	}
+	feedsFollowed, err := s.Db.GetFeedFollowsForUser(context.Background(), user.ID)
+	if err != nil {
+		return fmt.Errorf("unable to get user's feeds: %v", err)
+	}
+
+	// Convert each followed feed into an OPML subscription.
+	subscriptions := make([]opml.Subscription, 0, len(feedsFollowed))
+	for _, feed := range feedsFollowed {
+		subscriptions = append(subscriptions, opml.Subscription{
+			Title: feed.FeedName, XMLURL: feed.Url, HTMLURL: feed.SiteUrl.String,
+		})
+	}
+	doc := opml.New(fmt.Sprintf("%v's subscriptions", user.Name), subscriptions)
+
+	// Write to standard output unless a file was given.
+	if len(cmd.Arguments) == 0 {
+		return doc.Write(os.Stdout)
+	}
+	file, err := os.Create(cmd.Arguments[0])
+	if err != nil {
+		return fmt.Errorf("unable to create OPML file: %v", err)
+	}
+	defer file.Close()
+	if err := doc.Write(file); err != nil {
+		return err
 	}
+	fmt.Printf("Exported %v feeds to %v\n", len(subscriptions), cmd.Arguments[0])
 	return nil
 }
 
-// ScrapeFeeds fetches the next feed to be processed and stores its posts in the database.
+// ScrapeFeeds claims a batch of feeds that are due to be fetched and scrapes them
+// in parallel, storing their posts in the database.
+// Feeds are requested conditionally, so unchanged feeds are skipped without downloading them.
 //
 // Parameters:
 // - s: The current application state.
+// - concurrency: The maximum number of feeds scraped at the same time.
+// - batchSize: The maximum number of feeds claimed by this call.
 //
 // Returns:
-// - An error if the feed cannot be fetched or posts cannot be stored.
-func ScrapeFeeds(s *State) error {
-	// Retrieve the next feed to fetch, prioritized by last fetched time.
-	nextFeed, err := s.Db.GetNextFeedToFetch(context.Background())
+// - An error if the batch cannot be claimed, or the combined errors of the feeds that failed.
+func ScrapeFeeds(s *State, concurrency, batchSize int) error {
+	// Claim the feeds that are due by next_fetch_at, most overdue first, so other aggregators skip them.
+	feeds, err := s.Db.GetNextFeedsToFetch(context.Background(), int32(batchSize))
 	if err != nil {
-		return fmt.Errorf("unable to fetch next feed: %v", err)
+		return fmt.Errorf("unable to fetch next feeds: %v", err)
 	}
 
-	// Mark the feed as fetched with the current timestamp.
-	s.Db.MarkFeedFetched(context.Background(), nextFeed.ID)
+	// Scrape the claimed feeds, running at most `concurrency` at a time.
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var errs []error
+	sem := make(chan struct{}, concurrency)
+	for _, feed := range feeds {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(feed database.GetNextFeedsToFetchRow) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			if err := scrapeFeed(s, feed); err != nil {
+				mu.Lock()
+				errs = append(errs, fmt.Errorf("%v: %v", feed.Url, err))
+				mu.Unlock()
+			}
+		}(feed)
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
 
-	// Fetch the RSS feed from the given URL.
-	feed, err := rss.FetchFeed(context.Background(), nextFeed.Url)
+// scrapeFeed fetches a single claimed feed and stores its posts in the database,
+// reporting how many posts were inserted, updated, left unchanged or failed to be stored.
+// A post that fails to be stored does not stop the remaining posts from being processed.
+// A feed that has permanently moved is updated to its new URL first.
+//
+// Parameters:
+// - s: The current application state.
+// - nextFeed: The feed to scrape, as claimed by GetNextFeedsToFetch.
+//
+// Returns:
+// - An error if the feed cannot be fetched, or the combined errors of the posts that could not be stored.
+func scrapeFeed(s *State, nextFeed database.GetNextFeedsToFetchRow) error {
+	// Fetch the RSS feed from the given URL, unless it has not changed since the last fetch.
+	result, err := fetchWithRetry(s, nextFeed)
 	if err != nil {
+		// Remember the failure and back off before trying the feed again.
+		if recordErr := recordFetchFailure(s, nextFeed, err); recordErr != nil {
+			return errors.Join(fmt.Errorf("unable to get feed: %v", err), recordErr)
+		}
 		return fmt.Errorf("unable to get feed: %v", err)
 	}
 
-	// Process each item in the feed and store it as a post in the database.
+	// Follow the feed to its new home if it moved permanently.
+	if result.MovedTo != "" {
+		merged, err := moveFeed(s, nextFeed, result.MovedTo)
+		if err != nil {
+			return fmt.Errorf("unable to move feed: %v", err)
+		}
+		// Configured headers are looked up by URL, so they stop being sent after a move.
+		if _, ok := s.ConfigPtr.FeedHeaders[nextFeed.Url]; ok {
+			fmt.Printf("warning: feed_headers for %v no longer apply; move them to %v in the configuration\n", nextFeed.Url, result.MovedTo)
+		}
+		if merged {
+			// The feed at the new URL is fetched on its own schedule.
+			fmt.Printf("%v: moved to %v and merged into the feed already there\n", nextFeed.Url, result.MovedTo)
+			return nil
+		}
+		fmt.Printf("%v: moved to %v\n", nextFeed.Url, result.MovedTo)
+		nextFeed.Url = result.MovedTo
+	}
+
+	// The feed responded, so its failure streak is over.
+	err = s.Db.RecordFeedSuccess(context.Background(), database.RecordFeedSuccessParams{
+		ID:         nextFeed.ID,
+		LastStatus: sql.NullInt32{Int32: int32(result.StatusCode), Valid: true},
+	})
+	if err != nil {
+		return fmt.Errorf("unable to record fetch: %v", err)
+	}
+
+	// Schedule the next fetch based on how often the feed publishes.
+	err = scheduleFeed(s, nextFeed, result.Feed)
+	if err != nil {
+		return fmt.Errorf("unable to schedule feed: %v", err)
+	}
+
+	// Nothing changed since the last fetch, so there are no new posts to store.
+	if result.NotModified {
+		return saveCacheValidators(s, nextFeed.ID, result.Validators)
+	}
+	feed := result.Feed
+
+	// Keep the metadata the feed publishes about itself up to date.
+	err = s.Db.UpdateFeedMetadata(context.Background(), database.UpdateFeedMetadataParams{
+		ID:              nextFeed.ID,
+		SiteTitle:       parseToNullString(strings.TrimSpace(feed.Channel.Title)),
+		SiteUrl:         parseToNullString(strings.TrimSpace(feed.Channel.Link)),
+		SiteDescription: parseToNullString(render.Sanitize(strings.TrimSpace(feed.Channel.Description))),
+		Language:        parseToNullString(strings.TrimSpace(feed.Channel.Language)),
+		ImageUrl:        parseToNullString(strings.TrimSpace(feed.ImageURL())),
+		Generator:       parseToNullString(strings.TrimSpace(feed.Channel.Generator)),
+	})
+	if err != nil {
+		return fmt.Errorf("unable to update feed metadata: %v", err)
+	}
+
+	// Process each item in the feed, inserting new posts and updating changed ones.
+	// An item that cannot be stored is counted and reported without holding up the others.
+	var inserted, updated, unchanged, failed int
+	var errs []error
 	for _, item := range feed.Channel.Item {
+		// Items are deduplicated on their GUID, falling back to their link.
+		guid := item.Identity()
+		if guid == "" {
+			continue // Nothing to recognize the item by on the next fetch.
+		}
 		postID := uuid.New() // Generate a unique ID for the post.
-		err := s.Db.CreatePost(
+
+		// Posts stored before GUIDs were tracked are keyed by their URL; move them to the GUID
+		// so the upsert below updates them instead of storing the item a second time.
+		if nextFeed.HasLegacyPosts && item.Link != "" && guid != item.Link {
+			err := s.Db.RekeyLegacyPost(context.Background(), database.RekeyLegacyPostParams{
+				FeedID: nextFeed.ID, Guid: guid, Url: sql.NullString{String: item.Link, Valid: true},
+			})
+			if err != nil {
+				failed++
+				errs = append(errs, fmt.Errorf("unable to rekey post %v: %v", guid, err))
+				continue
+			}
+		}
+
+		// Items without a usable date are dated by when they were first seen.
+		publishedAt, dated := rss.ParseDate(item.PubDate)
+		if !dated {
+			publishedAt = time.Now()
+		}
+		isNew, err := s.Db.UpsertPost(
 			context.Background(),
-			database.CreatePostParams{
-				ID:          postID,
-				Title:       parseToNullString(item.Title),
-				Url:         parseToNullString(item.Link),
-				Description: parseToNullString(item.Description),
-				PublishedAt: parseToNullTime(item.PubDate),
-				FeedID:      nextFeed.ID,
+			database.UpsertPostParams{
+				ID:                  postID,
+				Title:               parseToNullString(item.Title),
+				Url:                 parseToNullString(item.Link),
+				Description:         parseToNullString(render.Sanitize(item.Description)),
+				PublishedAt:         sql.NullTime{Time: publishedAt.UTC(), Valid: true},
+				FeedID:              nextFeed.ID,
+				Guid:                guid,
+				Content:             parseToNullString(render.Sanitize(item.Content)),
+				Authors:             nonNilStrings(item.Authors),
+				Categories:          nonNilStrings(item.Categories),
+				PublishedAtInferred: !dated,
 			},
 		)
+		// The upsert returns no row when the stored post is already up to date.
+		isUnchanged := errors.Is(err, sql.ErrNoRows)
+		if err != nil && !isUnchanged {
+			failed++
+			errs = append(errs, fmt.Errorf("unable to store post %v: %v", guid, err))
+			continue
+		}
+
+		// Attach the item's media files to the stored post.
+		err = storeEnclosures(s, nextFeed.ID, guid, item)
+		switch {
+		case err != nil:
+			failed++
+			errs = append(errs, fmt.Errorf("unable to store enclosures of post %v: %v", guid, err))
+		case isUnchanged:
+			unchanged++
+		case isNew:
+			inserted++
+		default:
+			updated++
+		}
+	}
+	fmt.Printf("%v: %v inserted, %v updated, %v unchanged, %v failed\n", nextFeed.Url, inserted, updated, unchanged, failed)
+	if failed > 0 {
+		// Keep the previous validators so the next fetch returns the items that were not stored.
+		return errors.Join(errs...)
+	}
+
+	// Every item was stored under its GUID, so later fetches can skip rekeying.
+	if nextFeed.HasLegacyPosts {
+		err = s.Db.SetFeedLegacyPosts(context.Background(), database.SetFeedLegacyPostsParams{ID: nextFeed.ID, HasLegacyPosts: false})
 		if err != nil {
-			return fmt.Errorf("unable to create post: %v", err)
+			return fmt.Errorf("unable to update feed: %v", err)
 		}
 	}
-	return nil
+	return saveCacheValidators(s, nextFeed.ID, result.Validators)
 }
 
-// parseToNullTime converts a date string into a sql.NullTime value.
+// saveCacheValidators remembers a feed's validators so the next fetch can be conditional.
+// It is only called once everything the feed returned has been stored, because the
+// validators would otherwise let the next fetch skip the items that were lost.
 //
 // Parameters:
-// - date: The date string to parse.
+// - s: The application state containing the database.
+// - feedID: The ID of the feed.
+// - validators: The validators returned with the feed.
 //
 // Returns:
-// - A sql.NullTime value, which is valid if the date string matches any known format.
-func parseToNullTime(date string) sql.NullTime {
-	// List of possible date formats for parsing.
-	var dateFormats = []string{
-		time.RFC1123,          // "Mon, 02 Jan 2006 15:04:05 MST"
-		time.RFC1123Z,         // "Mon, 02 Jan 2006 15:04:05 -0700"
-		time.RFC3339,          // "2006-01-02T15:04:05Z07:00"
-		"2006-01-02T15:04:05", // ISO 8601 without time zone
-		"2006-01-02",          // Date only
+// - An error if the validators cannot be saved.
+func saveCacheValidators(s *State, feedID uuid.UUID, validators rss.CacheValidators) error {
+	err := s.Db.UpdateFeedCacheValidators(context.Background(), database.UpdateFeedCacheValidatorsParams{
+		ID:           feedID,
+		Etag:         parseToNullString(validators.ETag),
+		LastModified: parseToNullString(validators.LastModified),
+	})
+	if err != nil {
+		return fmt.Errorf("unable to update cache validators: %v", err)
 	}
+	return nil
+}
 
-	// Attempt to parse the date string using each format.
-	for _, format := range dateFormats {
-		parsedTime, err := time.Parse(format, date)
-		if err == nil {
-			return sql.NullTime{Time: parsedTime, Valid: true}
-		}
+// inferredNote marks publication dates that were not provided by the feed.
+//
+// Parameters:
+// - inferred: Whether the publication date is the time the post was first seen.
+//
+// Returns:
+// - A note to display after the date, or an empty string if the date is genuine.
+func inferredNote(inferred bool) string {
+	if inferred {
+		return " (first seen)"
 	}
-	return sql.NullTime{Valid: false} // Return an invalid sql.NullTime if parsing fails.
+	return ""
 }
 
 // parseToNullString converts a string into a sql.NullString value.
@@ -505,6 +1059,21 @@ func parseToNullString(s string) sql.NullString {
 	return sql.NullString{String: s, Valid: true}
 }
 
+// nonNilStrings returns a list that is never nil, so that it is stored as an empty
+// array rather than NULL.
+//
+// Parameters:
+// - values: The list to store.
+//
+// Returns:
+// - The list itself, or an empty list if it is nil.
+func nonNilStrings(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
+
 // getConfigFilePath constructs the full path to the configuration file.
 //
 // Returns:
@@ -0,0 +1,129 @@
+package config
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/seanhuebl/blog_aggregator/internal/database"
+	"github.com/seanhuebl/blog_aggregator/internal/rss"
+)
+
+// credentialSecret holds the part of a feed's credentials that is stored encrypted.
+type credentialSecret struct {
+	Username string `json:"username,omitempty"` // The user name for Basic auth
+	Password string `json:"password,omitempty"` // The password for Basic auth
+	Token    string `json:"token,omitempty"`    // The bearer token
+}
+
+// credentialsCipher creates the cipher feed credentials are encrypted with, from the configured key.
+//
+// Returns:
+// - An AES-256-GCM cipher.
+// - An error if no key is configured or it is not 32 bytes encoded as base64.
+func (c *Config) credentialsCipher() (cipher.AEAD, error) {
+	if c.CredentialsKey == "" {
+		return nil, fmt.Errorf("credentials_key must be set in the configuration to use feed credentials")
+	}
+	key, err := base64.StdEncoding.DecodeString(c.CredentialsKey)
+	if err != nil || len(key) != 32 {
+		return nil, fmt.Errorf("credentials_key must be 32 bytes encoded as base64")
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid credentials_key: %v", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+// storeFeedCredentials encrypts a feed's credentials and stores them, allowing them to be
+// sent only to the given host.
+//
+// Parameters:
+// - s: The current application state.
+// - queries: The queries to store the credentials with, such as those of a transaction.
+// - feedID: The ID of the feed.
+// - host: The host the credentials were given for.
+// - secret: The credentials to store.
+//
+// Returns:
+// - An error if the credentials cannot be encrypted or stored.
+func storeFeedCredentials(s *State, queries *database.Queries, feedID uuid.UUID, host string, secret credentialSecret) error {
+	aead, err := s.ConfigPtr.credentialsCipher()
+	if err != nil {
+		return err
+	}
+	plaintext, err := json.Marshal(secret)
+	if err != nil {
+		return fmt.Errorf("unable to encode credentials: %v", err)
+	}
+
+	// The nonce is stored in front of the ciphertext, which is bound to the feed it belongs to.
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return fmt.Errorf("unable to generate nonce: %v", err)
+	}
+	sealed := aead.Seal(nonce, nonce, plaintext, feedID[:])
+
+	err = queries.UpsertFeedCredentials(context.Background(), database.UpsertFeedCredentialsParams{
+		ID:     uuid.New(),
+		FeedID: feedID,
+		Host:   strings.ToLower(host),
+		Secret: sealed,
+	})
+	if err != nil {
+		return fmt.Errorf("unable to store credentials: %v", err)
+	}
+	return nil
+}
+
+// feedCredentials loads and decrypts the credentials of a feed.
+//
+// Parameters:
+// - s: The current application state.
+// - queries: The queries to load the credentials with, such as those of a transaction.
+// - feedID: The ID of the feed.
+//
+// Returns:
+// - A pointer to the credentials, or nil if the feed has none.
+// - An error if the credentials cannot be loaded or decrypted.
+func feedCredentials(s *State, queries *database.Queries, feedID uuid.UUID) (*rss.Credentials, error) {
+	stored, err := queries.GetFeedCredentials(context.Background(), feedID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to get credentials: %v", err)
+	}
+
+	aead, err := s.ConfigPtr.credentialsCipher()
+	if err != nil {
+		return nil, err
+	}
+	if len(stored.Secret) < aead.NonceSize() {
+		return nil, fmt.Errorf("stored credentials are corrupt")
+	}
+	nonce, ciphertext := stored.Secret[:aead.NonceSize()], stored.Secret[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, ciphertext, feedID[:])
+	if err != nil {
+		return nil, fmt.Errorf("unable to decrypt credentials, was credentials_key changed? %v", err)
+	}
+	var secret credentialSecret
+	if err := json.Unmarshal(plaintext, &secret); err != nil {
+		return nil, fmt.Errorf("unable to decode credentials: %v", err)
+	}
+	return &rss.Credentials{
+		Host:     stored.Host,
+		Username: secret.Username,
+		Password: secret.Password,
+		Token:    secret.Token,
+	}, nil
+}
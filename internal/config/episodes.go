@@ -0,0 +1,131 @@
+package config
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/seanhuebl/blog_aggregator/internal/database"
+	"github.com/seanhuebl/blog_aggregator/internal/render"
+	"github.com/seanhuebl/blog_aggregator/internal/rss"
+)
+
+// storeEnclosures saves the media files attached to a feed item, linking them to
+// the post stored for the item.
+//
+// Parameters:
+// - s: The current application state.
+// - feedID: The feed the item belongs to.
+// - guid: The GUID the item's post was stored under.
+// - item: The feed item.
+//
+// Returns:
+// - An error if an enclosure cannot be stored.
+func storeEnclosures(s *State, feedID uuid.UUID, guid string, item rss.RSSItem) error {
+	// The episode number, duration and artwork describe the item as a whole.
+	// Values too large for their columns are skipped rather than wrapped around.
+	episode := sql.NullInt32{}
+	if number, ok := item.Episode(); ok && number <= math.MaxInt32 {
+		episode = sql.NullInt32{Int32: int32(number), Valid: true}
+	}
+	itemDuration := sql.NullInt32{}
+	if duration, ok := item.Duration(); ok && duration/time.Second <= math.MaxInt32 {
+		itemDuration = sql.NullInt32{Int32: int32(duration / time.Second), Valid: true}
+	}
+
+	for _, enclosure := range item.Enclosures {
+		// Prefer a duration declared for the file itself.
+		duration := itemDuration
+		if seconds, err := strconv.ParseFloat(enclosure.Duration, 64); err == nil && seconds > 0 && seconds <= math.MaxInt32 {
+			duration = sql.NullInt32{Int32: int32(seconds), Valid: true}
+		}
+		length := sql.NullInt64{}
+		if size, err := strconv.ParseInt(strings.TrimSpace(enclosure.Length), 10, 64); err == nil && size > 0 {
+			length = sql.NullInt64{Int64: size, Valid: true}
+		}
+		err := s.Db.UpsertEnclosure(context.Background(), database.UpsertEnclosureParams{
+			ID:       uuid.New(),
+			Url:      enclosure.URL,
+			MimeType: parseToNullString(enclosure.Type),
+			Length:   length,
+			Duration: duration,
+			Episode:  episode,
+			ImageUrl: parseToNullString(item.Artwork()),
+			FeedID:   feedID,
+			Guid:     guid,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// HandlerEpisodes lists recent audio and video episodes from feeds that the current user follows.
+//
+// Parameters:
+// - s: The current application state.
+// - cmd: The command containing an optional limit argument.
+// - user: The currently logged-in user.
+//
+// Returns:
+// - An error if episodes cannot be retrieved or if the limit argument is invalid.
+func HandlerEpisodes(s *State, cmd Command, user database.User) error {
+	limit := 10 // Default limit if no argument is provided.
+	if len(cmd.Arguments) > 1 {
+		return fmt.Errorf("episodes takes up to one argument")
+	} else if len(cmd.Arguments) == 1 {
+		var err error
+		limit, err = strconv.Atoi(cmd.Arguments[0])
+		if err != nil {
+			return fmt.Errorf("episodes argument must be an integer: %v", err)
+		}
+	}
+
+	episodes, err := s.Db.GetEpisodesForUser(context.Background(), database.GetEpisodesForUserParams{
+		UserID: user.ID, Limit: int32(limit),
+	})
+	if err != nil {
+		return fmt.Errorf("error getting episodes: %v", err)
+	}
+
+	// Display the retrieved episodes.
+	for _, episode := range episodes {
+		title := episode.Title.String
+		if episode.Episode.Valid {
+			title = fmt.Sprintf("#%v %v", episode.Episode.Int32, title)
+		}
+		fmt.Printf("%v (%v)\n", render.StripControl(title), render.StripControl(episode.FeedName))
+		if episode.PublishedAt.Valid {
+			fmt.Printf("Published on: %v%v\n", episode.PublishedAt.Time, inferredNote(episode.PublishedAtInferred))
+		}
+		if episode.Duration.Valid {
+			fmt.Printf("Duration: %v\n", time.Duration(episode.Duration.Int32)*time.Second)
+		}
+		fmt.Printf("Download: %v (%v%v)\n", render.StripControl(episode.Url), render.StripControl(episode.MimeType.String), formatSize(episode.Length))
+		if episode.ImageUrl.Valid {
+			fmt.Printf("Artwork: %v\n", render.StripControl(episode.ImageUrl.String))
+		}
+		fmt.Println()
+	}
+	return nil
+}
+
+// formatSize renders a file size for display next to a media type.
+//
+// Parameters:
+// - length: The size of the file in bytes, if known.
+//
+// Returns:
+// - The size in megabytes prefixed with a comma, or an empty string if it is unknown.
+func formatSize(length sql.NullInt64) string {
+	if !length.Valid {
+		return ""
+	}
+	return fmt.Sprintf(", %.1f MB", float64(length.Int64)/(1<<20))
+}
@@ -0,0 +1,121 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/seanhuebl/blog_aggregator/internal/database"
+	"github.com/seanhuebl/blog_aggregator/internal/rss"
+)
+
+const (
+	// degradedAfterFailures is the number of consecutive failures after which a feed is degraded.
+	degradedAfterFailures = 3
+	// disabledAfterFailures is the number of consecutive failures after which a feed is disabled.
+	// With the backoff between failed fetches this takes about two weeks of failing.
+	disabledAfterFailures = 20
+)
+
+// feedStatusAfterFailure decides the lifecycle status of a feed that failed to be fetched.
+// A feed that is gone for good is disabled straight away; otherwise it is degraded and
+// eventually disabled as its failure streak grows.
+//
+// Parameters:
+// - failures: The number of consecutive failures, including the latest one.
+// - err: The error of the latest failure.
+//
+// Returns:
+// - The feed's new status.
+// - Why the feed is disabled, or an empty string if it is not.
+func feedStatusAfterFailure(failures int, err error) (database.FeedStatus, string) {
+	switch {
+	case rss.StatusCode(err) == http.StatusGone:
+		return database.FeedStatusDisabled, "the server reports the feed is gone (HTTP 410)"
+	case failures >= disabledAfterFailures:
+		return database.FeedStatusDisabled, fmt.Sprintf("failed %v times in a row", failures)
+	case failures >= degradedAfterFailures:
+		return database.FeedStatusDegraded, ""
+	default:
+		return database.FeedStatusActive, ""
+	}
+}
+
+// HandlerDisableFeed stops fetching a feed the current user follows until it is enabled again.
+//
+// Parameters:
+// - s: The current application state.
+// - cmd: The command containing the feed URL as an argument.
+// - user: The currently logged-in user.
+//
+// Returns:
+// - An error if the feed cannot be found, is not followed by the user or cannot be disabled.
+func HandlerDisableFeed(s *State, cmd Command, user database.User) error {
+	if len(cmd.Arguments) != 1 {
+		return fmt.Errorf("disable-feed takes one argument")
+	}
+	feed, err := followedFeed(s, user, cmd.Arguments[0])
+	if err != nil {
+		return err
+	}
+	err = s.Db.DisableFeed(context.Background(), database.DisableFeedParams{
+		ID:             feed.ID,
+		DisabledReason: parseToNullString(fmt.Sprintf("disabled by %v", user.Name)),
+	})
+	if err != nil {
+		return fmt.Errorf("unable to disable feed: %v", err)
+	}
+	fmt.Printf("Disabled %v\n", feed.Name)
+	return nil
+}
+
+// HandlerEnableFeed resumes fetching a disabled or failing feed the current user follows.
+// Its failure streak is cleared and it is fetched on the next aggregation tick.
+//
+// Parameters:
+// - s: The current application state.
+// - cmd: The command containing the feed URL as an argument.
+// - user: The currently logged-in user.
+//
+// Returns:
+// - An error if the feed cannot be found, is not followed by the user or cannot be enabled.
+func HandlerEnableFeed(s *State, cmd Command, user database.User) error {
+	if len(cmd.Arguments) != 1 {
+		return fmt.Errorf("enable-feed takes one argument")
+	}
+	feed, err := followedFeed(s, user, cmd.Arguments[0])
+	if err != nil {
+		return err
+	}
+	err = s.Db.EnableFeed(context.Background(), feed.ID)
+	if err != nil {
+		return fmt.Errorf("unable to enable feed: %v", err)
+	}
+	fmt.Printf("Enabled %v\n", feed.Name)
+	return nil
+}
+
+// followedFeed looks up a feed by its URL, making sure the user follows it.
+//
+// Parameters:
+// - s: The current application state.
+// - user: The currently logged-in user.
+// - url: The URL of the feed.
+//
+// Returns:
+// - The feed.
+// - An error if the feed cannot be found or the user does not follow it.
+func followedFeed(s *State, user database.User, url string) (database.GetFeedRow, error) {
+	feed, err := s.Db.GetFeed(context.Background(), url)
+	if err != nil {
+		return database.GetFeedRow{}, fmt.Errorf("unable to get feed: %v", err)
+	}
+	following, err := s.Db.IsFollowing(context.Background(), database.IsFollowingParams{UserID: user.ID, FeedID: feed.ID})
+	if err != nil {
+		return database.GetFeedRow{}, fmt.Errorf("unable to check follow: %v", err)
+	}
+	if !following {
+		return database.GetFeedRow{}, fmt.Errorf("you do not follow %v", url)
+	}
+	return feed, nil
+}
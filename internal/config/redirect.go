@@ -0,0 +1,126 @@
+package config
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/seanhuebl/blog_aggregator/internal/database"
+)
+
+// moveFeed updates a feed that has permanently moved to a new URL, remembering the old
+// URL so that it still finds the feed. If another feed already lives at the new URL, the
+// moved feed is merged into it: its follows, posts, previous URLs and credentials are handed
+// over and the moved feed is deleted. Everything happens in a single transaction.
+//
+// Parameters:
+// - s: The current application state.
+// - claimed: The feed as claimed by GetNextFeedsToFetch.
+// - newURL: The URL the feed permanently moved to.
+//
+// Returns:
+// - true if the feed was merged into an existing feed and no longer exists.
+// - An error if the feed cannot be moved.
+func moveFeed(s *State, claimed database.GetNextFeedsToFetchRow, newURL string) (bool, error) {
+	ctx := context.Background()
+	tx, err := s.Conn.BeginTx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("unable to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+	qtx := s.Db.WithTx(tx)
+
+	// Look for a feed already at the new URL, for example one added after the move.
+	targetID, err := qtx.GetFeedIDByURL(ctx, newURL)
+	merged := err == nil && targetID != claimed.ID
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return false, fmt.Errorf("unable to get feed: %v", err)
+	}
+
+	if merged {
+		// Hand everything over to the existing feed, then drop the moved one.
+		err = qtx.MoveFeedFollows(ctx, database.MoveFeedFollowsParams{FromFeedID: claimed.ID, ToFeedID: targetID})
+		if err != nil {
+			return false, fmt.Errorf("unable to move follows: %v", err)
+		}
+		err = qtx.MovePosts(ctx, database.MovePostsParams{FromFeedID: claimed.ID, ToFeedID: targetID})
+		if err != nil {
+			return false, fmt.Errorf("unable to move posts: %v", err)
+		}
+		if claimed.HasLegacyPosts {
+			// Posts keyed by their URL still need rekeying once they are in the other feed.
+			err = qtx.SetFeedLegacyPosts(ctx, database.SetFeedLegacyPostsParams{ID: targetID, HasLegacyPosts: true})
+			if err != nil {
+				return false, fmt.Errorf("unable to update feed: %v", err)
+			}
+		}
+		err = qtx.MoveFeedURLHistory(ctx, database.MoveFeedURLHistoryParams{FromFeedID: claimed.ID, ToFeedID: targetID})
+		if err != nil {
+			return false, fmt.Errorf("unable to move URL history: %v", err)
+		}
+		err = moveFeedCredentials(s, qtx, claimed.ID, targetID)
+		if err != nil {
+			return false, err
+		}
+		err = qtx.DeleteFeed(ctx, claimed.ID)
+		if err != nil {
+			return false, fmt.Errorf("unable to delete feed: %v", err)
+		}
+	} else {
+		// The new URL may have been one of the feed's earlier URLs.
+		targetID = claimed.ID
+		err = qtx.DeleteFeedURLHistory(ctx, newURL)
+		if err != nil {
+			return false, fmt.Errorf("unable to update URL history: %v", err)
+		}
+		err = qtx.UpdateFeedURL(ctx, database.UpdateFeedURLParams{ID: claimed.ID, Url: newURL})
+		if err != nil {
+			return false, fmt.Errorf("unable to update feed URL: %v", err)
+		}
+	}
+
+	// Keep the old URL leading to the feed, so following it still works.
+	err = qtx.AddFeedURLHistory(ctx, database.AddFeedURLHistoryParams{ID: uuid.New(), FeedID: targetID, Url: claimed.Url})
+	if err != nil {
+		return false, fmt.Errorf("unable to record previous URL: %v", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("unable to commit transaction: %v", err)
+	}
+	return merged, nil
+}
+
+// moveFeedCredentials hands the credentials of a feed being merged to the feed it is merged
+// into, unless that feed has credentials of its own. Credentials are encrypted for the feed
+// they belong to, so they are decrypted and encrypted again for the new feed rather than
+// copied, and would otherwise be lost when the merged feed is deleted.
+//
+// Parameters:
+// - s: The current application state.
+// - queries: The queries of the transaction merging the feeds.
+// - fromFeedID: The ID of the feed being merged.
+// - toFeedID: The ID of the feed it is merged into.
+//
+// Returns:
+// - An error if the credentials cannot be decrypted or stored again.
+func moveFeedCredentials(s *State, queries *database.Queries, fromFeedID, toFeedID uuid.UUID) error {
+	_, err := queries.GetFeedCredentials(context.Background(), toFeedID)
+	if err == nil {
+		return nil // The feed merged into keeps its own credentials.
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("unable to get credentials: %v", err)
+	}
+	credentials, err := feedCredentials(s, queries, fromFeedID)
+	if err != nil || credentials == nil {
+		return err
+	}
+	return storeFeedCredentials(s, queries, toFeedID, credentials.Host, credentialSecret{
+		Username: credentials.Username,
+		Password: credentials.Password,
+		Token:    credentials.Token,
+	})
+}
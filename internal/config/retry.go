@@ -0,0 +1,123 @@
+package config
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/seanhuebl/blog_aggregator/internal/database"
+	"github.com/seanhuebl/blog_aggregator/internal/rss"
+)
+
+const (
+	// maxFetchAttempts is the number of times a transient failure is tried before giving up.
+	maxFetchAttempts = 3
+	// retryBaseDelay is the wait before the first retry; it doubles with every attempt.
+	retryBaseDelay = 2 * time.Second
+	// maxRetryDelay is the longest wait between attempts; a longer Retry-After ends the retries.
+	maxRetryDelay = time.Minute
+)
+
+// fetchWithRetry fetches a claimed feed with the state's fetcher, retrying transient
+// failures with exponential backoff and honoring the delay a rate-limited server asks for.
+//
+// Parameters:
+// - s: The current application state.
+// - claimed: The feed as claimed by GetNextFeedsToFetch.
+//
+// Returns:
+// - The result of the first successful attempt.
+// - The error of the last attempt if none succeeded.
+func fetchWithRetry(s *State, claimed database.GetNextFeedsToFetchRow) (*rss.FetchResult, error) {
+	validators := rss.CacheValidators{ETag: claimed.Etag.String, LastModified: claimed.LastModified.String}
+
+	// Authenticate as the feed's stored credentials, if it has any.
+	ctx := context.Background()
+	credentials, err := feedCredentials(s, s.Db, claimed.ID)
+	if err != nil {
+		return nil, err
+	}
+	if credentials != nil {
+		ctx = rss.WithCredentials(ctx, *credentials)
+	}
+
+	delay := retryBaseDelay
+	for attempt := 1; ; attempt++ {
+		result, err := s.Fetcher.FetchFeedConditional(ctx, claimed.Url, validators)
+		if err == nil || attempt == maxFetchAttempts || !rss.IsTransient(err) {
+			return result, err
+		}
+
+		// Wait as long as the server asks, unless that is too long to wait for now.
+		wait := delay
+		if retryAfter := rss.RetryAfter(err); retryAfter > 0 {
+			if retryAfter > maxRetryDelay {
+				return nil, err
+			}
+			wait = retryAfter
+		}
+		time.Sleep(wait)
+		delay *= 2
+	}
+}
+
+// failureBackoff computes how long a failing feed is held back before it is fetched again.
+// The delay starts at the feed's fetch interval and doubles with every consecutive failure.
+//
+// Parameters:
+// - claimed: The feed as claimed by GetNextFeedsToFetch.
+// - failures: The number of consecutive failures, including the latest one.
+// - err: The error of the latest failure.
+//
+// Returns:
+// - The delay before the next fetch, at most maxFetchInterval unless the server asked for longer (up to maxPublisherInterval).
+func failureBackoff(claimed database.GetNextFeedsToFetchRow, failures int, err error) time.Duration {
+	delay := time.Duration(claimed.FetchInterval) * time.Second
+	if delay < minFetchInterval {
+		delay = minFetchInterval
+	}
+	for i := 1; i < failures && delay < maxFetchInterval; i++ {
+		delay *= 2
+	}
+	if delay > maxFetchInterval {
+		delay = maxFetchInterval
+	}
+
+	// Never come back sooner than a rate-limiting server asked us to, within reason.
+	if retryAfter := min(rss.RetryAfter(err), maxPublisherInterval); retryAfter > delay {
+		delay = retryAfter
+	}
+	return delay
+}
+
+// recordFetchFailure stores a failed fetch on the feed and schedules it after a backoff delay.
+// Feeds that keep failing are degraded and eventually disabled.
+//
+// Parameters:
+// - s: The current application state.
+// - claimed: The feed as claimed by GetNextFeedsToFetch.
+// - fetchErr: The error of the failed fetch.
+//
+// Returns:
+// - An error if the failure cannot be stored.
+func recordFetchFailure(s *State, claimed database.GetNextFeedsToFetchRow, fetchErr error) error {
+	failures := int(claimed.ConsecutiveFailures) + 1
+	status := sql.NullInt32{}
+	if code := rss.StatusCode(fetchErr); code != 0 {
+		status = sql.NullInt32{Int32: int32(code), Valid: true}
+	}
+	feedStatus, disabledReason := feedStatusAfterFailure(failures, fetchErr)
+	err := s.Db.RecordFeedFailure(context.Background(), database.RecordFeedFailureParams{
+		ID:             claimed.ID,
+		LastStatus:     status,
+		LastError:      parseToNullString(fetchErr.Error()),
+		Status:         feedStatus,
+		DisabledReason: parseToNullString(disabledReason),
+		Delay:          int32(failureBackoff(claimed, failures, fetchErr) / time.Second),
+	})
+	if err != nil {
+		return fmt.Errorf("unable to record fetch failure: %v", err)
+	}
+	return nil
+}
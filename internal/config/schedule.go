@@ -0,0 +1,120 @@
+package config
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/seanhuebl/blog_aggregator/internal/database"
+	"github.com/seanhuebl/blog_aggregator/internal/rss"
+)
+
+const (
+	// minFetchInterval is the shortest interval a feed is ever fetched at.
+	minFetchInterval = 15 * time.Minute
+	// maxFetchInterval is the longest interval adaptation can grow to; publisher hints may exceed it.
+	maxFetchInterval = 24 * time.Hour
+	// maxPublisherInterval is the longest interval a publisher's <ttl> or sy:updatePeriod can ask for.
+	maxPublisherInterval = 7 * 24 * time.Hour
+	// postingWindow is the number of most recent items used to estimate how often a feed posts.
+	postingWindow = 10
+)
+
+// nextFetchInterval adapts a feed's fetch interval to how often it publishes.
+//
+// Parameters:
+// - current: The interval the feed was fetched at.
+// - feed: The parsed feed, or nil if the server reported it as not modified.
+// - now: The time of the fetch.
+//
+// Returns:
+// - The interval to wait before the next fetch.
+func nextFetchInterval(current time.Duration, feed *rss.RSSFeed, now time.Time) time.Duration {
+	interval := current
+	if feed == nil {
+		// Nothing changed since the last fetch, so back off gradually.
+		interval = current * 3 / 2
+	} else if gap, ok := postingInterval(feed, now); ok {
+		// Aim to fetch about twice per posting gap, moving halfway there to smooth out bursts.
+		interval = (current + gap/2) / 2
+	}
+
+	// Keep the interval within sensible bounds.
+	if interval < minFetchInterval {
+		interval = minFetchInterval
+	}
+	if interval > maxFetchInterval {
+		interval = maxFetchInterval
+	}
+
+	// Never fetch more often than the publisher asks us to, within reason: a yearly
+	// update period or a huge <ttl> would all but stop fetching the feed.
+	if feed != nil {
+		publisherMin := feed.MinRefreshInterval()
+		if publisherMin > maxPublisherInterval {
+			publisherMin = maxPublisherInterval
+		}
+		if interval < publisherMin {
+			interval = publisherMin
+		}
+	}
+	return interval
+}
+
+// postingInterval estimates the average time between posts in a feed.
+//
+// Parameters:
+// - feed: The parsed feed.
+// - now: The time of the fetch, used to account for feeds that have gone quiet.
+//
+// Returns:
+// - The estimated interval between posts.
+// - false if the feed has too few dated items to make an estimate.
+func postingInterval(feed *rss.RSSFeed, now time.Time) (time.Duration, bool) {
+	var dates []time.Time
+	for _, item := range feed.Channel.Item {
+		if published, ok := rss.ParseDate(item.PubDate); ok {
+			dates = append(dates, published)
+		}
+	}
+	if len(dates) < 2 {
+		return 0, false
+	}
+
+	// Average the gaps between the most recent items.
+	sort.Slice(dates, func(i, j int) bool { return dates[i].After(dates[j]) })
+	if len(dates) > postingWindow {
+		dates = dates[:postingWindow]
+	}
+	gap := dates[0].Sub(dates[len(dates)-1]) / time.Duration(len(dates)-1)
+
+	// A feed that has not posted for longer than its usual gap is slowing down.
+	if quiet := now.Sub(dates[0]); quiet > gap {
+		gap = quiet
+	}
+	return gap, true
+}
+
+// scheduleFeed stores a feed's adapted fetch interval and when it is next due,
+// honoring the publisher's <skipHours> and <skipDays>.
+//
+// Parameters:
+// - s: The current application state.
+// - claimed: The feed as claimed by GetNextFeedsToFetch.
+// - feed: The parsed feed, or nil if the server reported it as not modified.
+//
+// Returns:
+// - An error if the schedule cannot be stored.
+func scheduleFeed(s *State, claimed database.GetNextFeedsToFetchRow, feed *rss.RSSFeed) error {
+	now := time.Now()
+	interval := nextFetchInterval(time.Duration(claimed.FetchInterval)*time.Second, feed, now)
+	next := now.Add(interval)
+	if feed != nil {
+		next = feed.NextAllowedFetch(next)
+	}
+	return s.Db.ScheduleFeed(context.Background(), database.ScheduleFeedParams{
+		ID:            claimed.ID,
+		FetchInterval: int32(interval / time.Second),
+		Delay:         int32(next.Sub(now) / time.Second),
+	})
+}
@@ -0,0 +1,59 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/seanhuebl/blog_aggregator/internal/database"
+	"github.com/seanhuebl/blog_aggregator/internal/render"
+)
+
+// HandlerFeedStatus shows how fetching each feed is going: when it was last fetched and
+// is next due, the latest HTTP status and, for failing feeds, their latest error.
+// Disabled feeds are listed first, then degraded and other failing feeds.
+//
+// Parameters:
+// - s: The current application state.
+// - cmd: The command with no arguments.
+//
+// Returns:
+// - An error if the statuses cannot be retrieved.
+func HandlerFeedStatus(s *State, cmd Command) error {
+	if len(cmd.Arguments) != 0 {
+		return fmt.Errorf("feed-status takes zero arguments")
+	}
+	feeds, err := s.Db.GetFeedStatuses(context.Background())
+	if err != nil {
+		return fmt.Errorf("unable to get feed statuses: %v", err)
+	}
+
+	for _, feed := range feeds {
+		health := "OK"
+		switch {
+		case feed.Status == database.FeedStatusDisabled:
+			health = fmt.Sprintf("DISABLED (%v)", feed.DisabledReason.String)
+		case feed.Status == database.FeedStatusDegraded:
+			health = fmt.Sprintf("DEGRADED (%v failures in a row)", feed.ConsecutiveFailures)
+		case feed.ConsecutiveFailures > 0:
+			health = fmt.Sprintf("FAILING (%v in a row)", feed.ConsecutiveFailures)
+		case !feed.LastFetchedAt.Valid:
+			health = "NEVER FETCHED"
+		}
+		fmt.Printf("%v (%v): %v\n", render.StripControl(feed.Name), render.StripControl(feed.Url), health)
+		if feed.LastFetchedAt.Valid {
+			fmt.Printf("  Last fetched: %v\n", feed.LastFetchedAt.Time)
+		}
+		if feed.NextFetchAt.Valid && feed.Status != database.FeedStatusDisabled {
+			fmt.Printf("  Next fetch: %v\n", feed.NextFetchAt.Time)
+		}
+		fmt.Printf("  Fetch interval: %v\n", time.Duration(feed.FetchInterval)*time.Second)
+		if feed.LastStatus.Valid {
+			fmt.Printf("  Last HTTP status: %v\n", feed.LastStatus.Int32)
+		}
+		if feed.LastError.Valid {
+			fmt.Printf("  Last error: %v (at %v)\n", render.StripControl(feed.LastError.String), feed.LastErrorAt.Time)
+		}
+	}
+	return nil
+}
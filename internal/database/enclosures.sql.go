@@ -0,0 +1,148 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.27.0
+// source: enclosures.sql
+
+package database
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+)
+
+const getEpisodesForUser = `-- name: GetEpisodesForUser :many
+SELECT posts.title,
+    posts.published_at,
+    posts.published_at_inferred,
+    feeds.name AS feed_name,
+    enclosures.url,
+    enclosures.mime_type,
+    enclosures.length,
+    enclosures.duration,
+    enclosures.episode,
+    enclosures.image_url
+FROM enclosures
+    INNER JOIN posts ON posts.id = enclosures.post_id
+    INNER JOIN feeds ON feeds.id = posts.feed_id
+    INNER JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
+WHERE feed_follows.user_id = $1
+    AND (
+        enclosures.mime_type LIKE 'audio/%'
+        OR enclosures.mime_type LIKE 'video/%'
+    )
+ORDER BY posts.published_at DESC NULLS LAST
+LIMIT $2
+`
+
+type GetEpisodesForUserParams struct {
+	UserID uuid.UUID
+	Limit  int32
+}
+
+type GetEpisodesForUserRow struct {
+	Title               sql.NullString
+	PublishedAt         sql.NullTime
+	PublishedAtInferred bool
+	FeedName            string
+	Url                 string
+	MimeType            sql.NullString
+	Length              sql.NullInt64
+	Duration            sql.NullInt32
+	Episode             sql.NullInt32
+	ImageUrl            sql.NullString
+}
+
+func (q *Queries) GetEpisodesForUser(ctx context.Context, arg GetEpisodesForUserParams) ([]GetEpisodesForUserRow, error) {
+	rows, err := q.db.QueryContext(ctx, getEpisodesForUser, arg.UserID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetEpisodesForUserRow
+	for rows.Next() {
+		var i GetEpisodesForUserRow
+		if err := rows.Scan(
+			&i.Title,
+			&i.PublishedAt,
+			&i.PublishedAtInferred,
+			&i.FeedName,
+			&i.Url,
+			&i.MimeType,
+			&i.Length,
+			&i.Duration,
+			&i.Episode,
+			&i.ImageUrl,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const upsertEnclosure = `-- name: UpsertEnclosure :exec
+INSERT INTO enclosures (
+        id,
+        post_id,
+        url,
+        mime_type,
+        length,
+        duration,
+        episode,
+        image_url
+    )
+SELECT $1,
+    posts.id,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6,
+    $7
+FROM posts
+WHERE posts.feed_id = $8
+    AND posts.guid = $9
+    ON CONFLICT (post_id, url) DO
+UPDATE
+SET mime_type = EXCLUDED.mime_type,
+    length = EXCLUDED.length,
+    duration = EXCLUDED.duration,
+    episode = EXCLUDED.episode,
+    image_url = EXCLUDED.image_url,
+    updated_at = CURRENT_TIMESTAMP
+`
+
+type UpsertEnclosureParams struct {
+	ID       uuid.UUID
+	Url      string
+	MimeType sql.NullString
+	Length   sql.NullInt64
+	Duration sql.NullInt32
+	Episode  sql.NullInt32
+	ImageUrl sql.NullString
+	FeedID   uuid.UUID
+	Guid     string
+}
+
+func (q *Queries) UpsertEnclosure(ctx context.Context, arg UpsertEnclosureParams) error {
+	_, err := q.db.ExecContext(ctx, upsertEnclosure,
+		arg.ID,
+		arg.Url,
+		arg.MimeType,
+		arg.Length,
+		arg.Duration,
+		arg.Episode,
+		arg.ImageUrl,
+		arg.FeedID,
+		arg.Guid,
+	)
+	return err
+}
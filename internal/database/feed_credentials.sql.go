@@ -0,0 +1,57 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.27.0
+// source: feed_credentials.sql
+
+package database
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+const getFeedCredentials = `-- name: GetFeedCredentials :one
+SELECT host,
+    secret
+FROM feed_credentials
+WHERE feed_id = $1
+`
+
+type GetFeedCredentialsRow struct {
+	Host   string
+	Secret []byte
+}
+
+func (q *Queries) GetFeedCredentials(ctx context.Context, feedID uuid.UUID) (GetFeedCredentialsRow, error) {
+	row := q.db.QueryRowContext(ctx, getFeedCredentials, feedID)
+	var i GetFeedCredentialsRow
+	err := row.Scan(&i.Host, &i.Secret)
+	return i, err
+}
+
+const upsertFeedCredentials = `-- name: UpsertFeedCredentials :exec
+INSERT INTO feed_credentials (id, feed_id, host, secret)
+VALUES ($1, $2, $3, $4) ON CONFLICT (feed_id) DO
+UPDATE
+SET host = EXCLUDED.host,
+    secret = EXCLUDED.secret,
+    updated_at = CURRENT_TIMESTAMP
+`
+
+type UpsertFeedCredentialsParams struct {
+	ID     uuid.UUID
+	FeedID uuid.UUID
+	Host   string
+	Secret []byte
+}
+
+func (q *Queries) UpsertFeedCredentials(ctx context.Context, arg UpsertFeedCredentialsParams) error {
+	_, err := q.db.ExecContext(ctx, upsertFeedCredentials,
+		arg.ID,
+		arg.FeedID,
+		arg.Host,
+		arg.Secret,
+	)
+	return err
+}
@@ -75,7 +75,7 @@ func (q *Queries) CreateFeedFollow(ctx context.Context, arg CreateFeedFollowPara
 }
 
 const getFeedFollowsForUser = `-- name: GetFeedFollowsForUser :many
-SELECT feed_follows.id, feed_follows.created_at, feed_follows.updated_at, feed_follows.user_id, feed_id, users.id, users.created_at, users.updated_at, users.name, feeds.id, feeds.name, url, feeds.created_at, feeds.updated_at, feeds.user_id, last_fetched_at,
+SELECT feed_follows.id, feed_follows.created_at, feed_follows.updated_at, feed_follows.user_id, feed_id, users.id, users.created_at, users.updated_at, users.name, feeds.id, feeds.name, url, feeds.created_at, feeds.updated_at, feeds.user_id, last_fetched_at, etag, last_modified, next_fetch_at, fetch_interval, site_title, site_url, site_description, language, image_url, generator, last_status, last_error, last_error_at, consecutive_failures, status, disabled_reason, has_legacy_posts,
     feeds.name AS feed_name,
     users.name AS user_name
 FROM feed_follows
@@ -85,24 +85,41 @@ WHERE users.id = $1
 `
 
 type GetFeedFollowsForUserRow struct {
-	ID            uuid.UUID
-	CreatedAt     time.Time
-	UpdatedAt     time.Time
-	UserID        uuid.UUID
-	FeedID        uuid.UUID
-	ID_2          uuid.UUID
-	CreatedAt_2   time.Time
-	UpdatedAt_2   time.Time
-	Name          string
-	ID_3          uuid.UUID
-	Name_2        string
-	Url           string
-	CreatedAt_3   time.Time
-	UpdatedAt_3   time.Time
-	UserID_2      uuid.UUID
-	LastFetchedAt sql.NullTime
-	FeedName      string
-	UserName      string
+	ID                  uuid.UUID
+	CreatedAt           time.Time
+	UpdatedAt           time.Time
+	UserID              uuid.UUID
+	FeedID              uuid.UUID
+	ID_2                uuid.UUID
+	CreatedAt_2         time.Time
+	UpdatedAt_2         time.Time
+	Name                string
+	ID_3                uuid.UUID
+	Name_2              string
+	Url                 string
+	CreatedAt_3         time.Time
+	UpdatedAt_3         time.Time
+	UserID_2            uuid.UUID
+	LastFetchedAt       sql.NullTime
+	Etag                sql.NullString
+	LastModified        sql.NullString
+	NextFetchAt         sql.NullTime
+	FetchInterval       int32
+	SiteTitle           sql.NullString
+	SiteUrl             sql.NullString
+	SiteDescription     sql.NullString
+	Language            sql.NullString
+	ImageUrl            sql.NullString
+	Generator           sql.NullString
+	LastStatus          sql.NullInt32
+	LastError           sql.NullString
+	LastErrorAt         sql.NullTime
+	ConsecutiveFailures int32
+	Status              FeedStatus
+	DisabledReason      sql.NullString
+	HasLegacyPosts      bool
+	FeedName            string
+	UserName            string
 }
 
 func (q *Queries) GetFeedFollowsForUser(ctx context.Context, id uuid.UUID) ([]GetFeedFollowsForUserRow, error) {
@@ -131,6 +148,23 @@ func (q *Queries) GetFeedFollowsForUser(ctx context.Context, id uuid.UUID) ([]Ge
 			&i.UpdatedAt_3,
 			&i.UserID_2,
 			&i.LastFetchedAt,
+			&i.Etag,
+			&i.LastModified,
+			&i.NextFetchAt,
+			&i.FetchInterval,
+			&i.SiteTitle,
+			&i.SiteUrl,
+			&i.SiteDescription,
+			&i.Language,
+			&i.ImageUrl,
+			&i.Generator,
+			&i.LastStatus,
+			&i.LastError,
+			&i.LastErrorAt,
+			&i.ConsecutiveFailures,
+			&i.Status,
+			&i.DisabledReason,
+			&i.HasLegacyPosts,
 			&i.FeedName,
 			&i.UserName,
 		); err != nil {
@@ -147,6 +181,49 @@ func (q *Queries) GetFeedFollowsForUser(ctx context.Context, id uuid.UUID) ([]Ge
 	return items, nil
 }
 
+const isFollowing = `-- name: IsFollowing :one
+SELECT EXISTS (
+        SELECT 1
+        FROM feed_follows
+        WHERE user_id = $1
+            AND feed_id = $2
+    )
+`
+
+type IsFollowingParams struct {
+	UserID uuid.UUID
+	FeedID uuid.UUID
+}
+
+func (q *Queries) IsFollowing(ctx context.Context, arg IsFollowingParams) (bool, error) {
+	row := q.db.QueryRowContext(ctx, isFollowing, arg.UserID, arg.FeedID)
+	var exists bool
+	err := row.Scan(&exists)
+	return exists, err
+}
+
+const moveFeedFollows = `-- name: MoveFeedFollows :exec
+UPDATE feed_follows
+SET feed_id = $1,
+    updated_at = CURRENT_TIMESTAMP
+WHERE feed_follows.feed_id = $2
+    AND feed_follows.user_id NOT IN (
+        SELECT existing.user_id
+        FROM feed_follows AS existing
+        WHERE existing.feed_id = $1
+    )
+`
+
+type MoveFeedFollowsParams struct {
+	ToFeedID   uuid.UUID
+	FromFeedID uuid.UUID
+}
+
+func (q *Queries) MoveFeedFollows(ctx context.Context, arg MoveFeedFollowsParams) error {
+	_, err := q.db.ExecContext(ctx, moveFeedFollows, arg.ToFeedID, arg.FromFeedID)
+	return err
+}
+
 const unfollow = `-- name: Unfollow :exec
 DELETE FROM feed_follows
 WHERE user_id = $1
@@ -0,0 +1,58 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.27.0
+// source: feed_url_history.sql
+
+package database
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+const addFeedURLHistory = `-- name: AddFeedURLHistory :exec
+INSERT INTO feed_url_history (id, feed_id, url)
+VALUES ($1, $2, $3) ON CONFLICT (url) DO
+UPDATE
+SET feed_id = EXCLUDED.feed_id,
+    updated_at = CURRENT_TIMESTAMP
+`
+
+type AddFeedURLHistoryParams struct {
+	ID     uuid.UUID
+	FeedID uuid.UUID
+	Url    string
+}
+
+func (q *Queries) AddFeedURLHistory(ctx context.Context, arg AddFeedURLHistoryParams) error {
+	_, err := q.db.ExecContext(ctx, addFeedURLHistory, arg.ID, arg.FeedID, arg.Url)
+	return err
+}
+
+const deleteFeedURLHistory = `-- name: DeleteFeedURLHistory :exec
+DELETE FROM feed_url_history
+WHERE url = $1
+`
+
+func (q *Queries) DeleteFeedURLHistory(ctx context.Context, url string) error {
+	_, err := q.db.ExecContext(ctx, deleteFeedURLHistory, url)
+	return err
+}
+
+const moveFeedURLHistory = `-- name: MoveFeedURLHistory :exec
+UPDATE feed_url_history
+SET feed_id = $1,
+    updated_at = CURRENT_TIMESTAMP
+WHERE feed_id = $2
+`
+
+type MoveFeedURLHistoryParams struct {
+	ToFeedID   uuid.UUID
+	FromFeedID uuid.UUID
+}
+
+func (q *Queries) MoveFeedURLHistory(ctx context.Context, arg MoveFeedURLHistoryParams) error {
+	_, err := q.db.ExecContext(ctx, moveFeedURLHistory, arg.ToFeedID, arg.FromFeedID)
+	return err
+}
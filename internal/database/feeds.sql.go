@@ -7,6 +7,7 @@ package database
 
 import (
 	"context"
+	"database/sql"
 
 	"github.com/google/uuid"
 )
@@ -14,7 +15,7 @@ import (
 const addFeed = `-- name: AddFeed :one
 INSERT INTO feeds (id, name, url, user_id)
 VALUES ($1, $2, $3, $4)
-RETURNING id, name, url, created_at, updated_at, user_id, last_fetched_at
+RETURNING id, name, url, created_at, updated_at, user_id, last_fetched_at, etag, last_modified, next_fetch_at, fetch_interval, site_title, site_url, site_description, language, image_url, generator, last_status, last_error, last_error_at, consecutive_failures, status, disabled_reason, has_legacy_posts
 `
 
 type AddFeedParams struct {
@@ -40,15 +41,82 @@ func (q *Queries) AddFeed(ctx context.Context, arg AddFeedParams) (Feed, error)
 		&i.UpdatedAt,
 		&i.UserID,
 		&i.LastFetchedAt,
+		&i.Etag,
+		&i.LastModified,
+		&i.NextFetchAt,
+		&i.FetchInterval,
+		&i.SiteTitle,
+		&i.SiteUrl,
+		&i.SiteDescription,
+		&i.Language,
+		&i.ImageUrl,
+		&i.Generator,
+		&i.LastStatus,
+		&i.LastError,
+		&i.LastErrorAt,
+		&i.ConsecutiveFailures,
+		&i.Status,
+		&i.DisabledReason,
+		&i.HasLegacyPosts,
 	)
 	return i, err
 }
 
+const deleteFeed = `-- name: DeleteFeed :exec
+DELETE FROM feeds
+WHERE id = $1
+`
+
+func (q *Queries) DeleteFeed(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, deleteFeed, id)
+	return err
+}
+
+const disableFeed = `-- name: DisableFeed :exec
+UPDATE feeds
+SET status = 'disabled',
+    disabled_reason = $2,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = $1
+`
+
+type DisableFeedParams struct {
+	ID             uuid.UUID
+	DisabledReason sql.NullString
+}
+
+func (q *Queries) DisableFeed(ctx context.Context, arg DisableFeedParams) error {
+	_, err := q.db.ExecContext(ctx, disableFeed, arg.ID, arg.DisabledReason)
+	return err
+}
+
+const enableFeed = `-- name: EnableFeed :exec
+UPDATE feeds
+SET status = 'active',
+    disabled_reason = NULL,
+    consecutive_failures = 0,
+    next_fetch_at = NULL,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = $1
+`
+
+func (q *Queries) EnableFeed(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, enableFeed, id)
+	return err
+}
+
 const getFeed = `-- name: GetFeed :one
 SELECT id,
     name
 FROM feeds
-WHERE url = $1
+WHERE feeds.url = $1
+    OR feeds.id IN (
+        SELECT feed_url_history.feed_id
+        FROM feed_url_history
+        WHERE feed_url_history.url = $1
+    )
+ORDER BY feeds.url = $1 DESC
+LIMIT 1
 `
 
 type GetFeedRow struct {
@@ -63,18 +131,111 @@ func (q *Queries) GetFeed(ctx context.Context, url string) (GetFeedRow, error) {
 	return i, err
 }
 
+const getFeedIDByURL = `-- name: GetFeedIDByURL :one
+SELECT id
+FROM feeds
+WHERE url = $1 FOR
+UPDATE
+`
+
+func (q *Queries) GetFeedIDByURL(ctx context.Context, url string) (uuid.UUID, error) {
+	row := q.db.QueryRowContext(ctx, getFeedIDByURL, url)
+	var id uuid.UUID
+	err := row.Scan(&id)
+	return id, err
+}
+
+const getFeedStatuses = `-- name: GetFeedStatuses :many
+SELECT name,
+    url,
+    status,
+    disabled_reason,
+    last_fetched_at,
+    next_fetch_at,
+    fetch_interval,
+    last_status,
+    consecutive_failures,
+    last_error,
+    last_error_at
+FROM feeds
+ORDER BY status DESC,
+    consecutive_failures DESC,
+    name ASC
+`
+
+type GetFeedStatusesRow struct {
+	Name                string
+	Url                 string
+	Status              FeedStatus
+	DisabledReason      sql.NullString
+	LastFetchedAt       sql.NullTime
+	NextFetchAt         sql.NullTime
+	FetchInterval       int32
+	LastStatus          sql.NullInt32
+	ConsecutiveFailures int32
+	LastError           sql.NullString
+	LastErrorAt         sql.NullTime
+}
+
+func (q *Queries) GetFeedStatuses(ctx context.Context) ([]GetFeedStatusesRow, error) {
+	rows, err := q.db.QueryContext(ctx, getFeedStatuses)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetFeedStatusesRow
+	for rows.Next() {
+		var i GetFeedStatusesRow
+		if err := rows.Scan(
+			&i.Name,
+			&i.Url,
+			&i.Status,
+			&i.DisabledReason,
+			&i.LastFetchedAt,
+			&i.NextFetchAt,
+			&i.FetchInterval,
+			&i.LastStatus,
+			&i.ConsecutiveFailures,
+			&i.LastError,
+			&i.LastErrorAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getFeeds = `-- name: GetFeeds :many
 SELECT feeds.name,
     feeds.url,
+    feeds.site_title,
+    feeds.site_url,
+    feeds.site_description,
+    feeds.language,
+    feeds.image_url,
+    feeds.generator,
     users.name
 FROM feeds
     INNER JOIN users ON feeds.user_id = users.id
 `
 
 type GetFeedsRow struct {
-	Name   string
-	Url    string
-	Name_2 string
+	Name            string
+	Url             string
+	SiteTitle       sql.NullString
+	SiteUrl         sql.NullString
+	SiteDescription sql.NullString
+	Language        sql.NullString
+	ImageUrl        sql.NullString
+	Generator       sql.NullString
+	Name_2          string
 }
 
 func (q *Queries) GetFeeds(ctx context.Context) ([]GetFeedsRow, error) {
@@ -86,7 +247,17 @@ func (q *Queries) GetFeeds(ctx context.Context) ([]GetFeedsRow, error) {
 	var items []GetFeedsRow
 	for rows.Next() {
 		var i GetFeedsRow
-		if err := rows.Scan(&i.Name, &i.Url, &i.Name_2); err != nil {
+		if err := rows.Scan(
+			&i.Name,
+			&i.Url,
+			&i.SiteTitle,
+			&i.SiteUrl,
+			&i.SiteDescription,
+			&i.Language,
+			&i.ImageUrl,
+			&i.Generator,
+			&i.Name_2,
+		); err != nil {
 			return nil, err
 		}
 		items = append(items, i)
@@ -100,34 +271,233 @@ func (q *Queries) GetFeeds(ctx context.Context) ([]GetFeedsRow, error) {
 	return items, nil
 }
 
-const getNextFeedToFetch = `-- name: GetNextFeedToFetch :one
-SELECT id,
-    url
-FROM feeds
-ORDER BY last_fetched_at ASC NULLS FIRST
-LIMIT 1
+const getNextFeedsToFetch = `-- name: GetNextFeedsToFetch :many
+UPDATE feeds
+SET last_fetched_at = CURRENT_TIMESTAMP,
+    next_fetch_at = CURRENT_TIMESTAMP + fetch_interval * INTERVAL '1 second',
+    updated_at = CURRENT_TIMESTAMP
+WHERE id IN (
+        SELECT id
+        FROM feeds
+        WHERE status <> 'disabled'
+            AND (
+                next_fetch_at IS NULL
+                OR next_fetch_at <= CURRENT_TIMESTAMP
+            )
+        ORDER BY next_fetch_at ASC NULLS FIRST,
+            last_fetched_at ASC NULLS FIRST
+        LIMIT $1
+        FOR UPDATE SKIP LOCKED
+    )
+RETURNING id,
+    url,
+    etag,
+    last_modified,
+    fetch_interval,
+    consecutive_failures,
+    has_legacy_posts
 `
 
-type GetNextFeedToFetchRow struct {
-	ID  uuid.UUID
-	Url string
+type GetNextFeedsToFetchRow struct {
+	ID                  uuid.UUID
+	Url                 string
+	Etag                sql.NullString
+	LastModified        sql.NullString
+	FetchInterval       int32
+	ConsecutiveFailures int32
+	HasLegacyPosts      bool
 }
 
-func (q *Queries) GetNextFeedToFetch(ctx context.Context) (GetNextFeedToFetchRow, error) {
-	row := q.db.QueryRowContext(ctx, getNextFeedToFetch)
-	var i GetNextFeedToFetchRow
-	err := row.Scan(&i.ID, &i.Url)
-	return i, err
+func (q *Queries) GetNextFeedsToFetch(ctx context.Context, limit int32) ([]GetNextFeedsToFetchRow, error) {
+	rows, err := q.db.QueryContext(ctx, getNextFeedsToFetch, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetNextFeedsToFetchRow
+	for rows.Next() {
+		var i GetNextFeedsToFetchRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Url,
+			&i.Etag,
+			&i.LastModified,
+			&i.FetchInterval,
+			&i.ConsecutiveFailures,
+			&i.HasLegacyPosts,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
 }
 
-const markFeedFetched = `-- name: MarkFeedFetched :exec
+const recordFeedFailure = `-- name: RecordFeedFailure :exec
 UPDATE feeds
-SET last_fetched_at = CURRENT_TIMESTAMP,
+SET last_status = $1,
+    last_error = $2,
+    last_error_at = CURRENT_TIMESTAMP,
+    consecutive_failures = consecutive_failures + 1,
+    status = $3,
+    disabled_reason = $4,
+    next_fetch_at = CURRENT_TIMESTAMP + $5::INTEGER * INTERVAL '1 second',
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = $6
+`
+
+type RecordFeedFailureParams struct {
+	LastStatus     sql.NullInt32
+	LastError      sql.NullString
+	Status         FeedStatus
+	DisabledReason sql.NullString
+	Delay          int32
+	ID             uuid.UUID
+}
+
+func (q *Queries) RecordFeedFailure(ctx context.Context, arg RecordFeedFailureParams) error {
+	_, err := q.db.ExecContext(ctx, recordFeedFailure,
+		arg.LastStatus,
+		arg.LastError,
+		arg.Status,
+		arg.DisabledReason,
+		arg.Delay,
+		arg.ID,
+	)
+	return err
+}
+
+const recordFeedSuccess = `-- name: RecordFeedSuccess :exec
+UPDATE feeds
+SET last_status = $2,
+    consecutive_failures = 0,
+    status = CASE
+        WHEN status = 'degraded' THEN 'active'
+        ELSE status
+    END,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = $1
+`
+
+type RecordFeedSuccessParams struct {
+	ID         uuid.UUID
+	LastStatus sql.NullInt32
+}
+
+func (q *Queries) RecordFeedSuccess(ctx context.Context, arg RecordFeedSuccessParams) error {
+	_, err := q.db.ExecContext(ctx, recordFeedSuccess, arg.ID, arg.LastStatus)
+	return err
+}
+
+const scheduleFeed = `-- name: ScheduleFeed :exec
+UPDATE feeds
+SET fetch_interval = $1,
+    next_fetch_at = CURRENT_TIMESTAMP + $2::INTEGER * INTERVAL '1 second',
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = $3
+`
+
+type ScheduleFeedParams struct {
+	FetchInterval int32
+	Delay         int32
+	ID            uuid.UUID
+}
+
+func (q *Queries) ScheduleFeed(ctx context.Context, arg ScheduleFeedParams) error {
+	_, err := q.db.ExecContext(ctx, scheduleFeed, arg.FetchInterval, arg.Delay, arg.ID)
+	return err
+}
+
+const setFeedLegacyPosts = `-- name: SetFeedLegacyPosts :exec
+UPDATE feeds
+SET has_legacy_posts = $2,
     updated_at = CURRENT_TIMESTAMP
 WHERE id = $1
 `
 
-func (q *Queries) MarkFeedFetched(ctx context.Context, id uuid.UUID) error {
-	_, err := q.db.ExecContext(ctx, markFeedFetched, id)
+type SetFeedLegacyPostsParams struct {
+	ID             uuid.UUID
+	HasLegacyPosts bool
+}
+
+func (q *Queries) SetFeedLegacyPosts(ctx context.Context, arg SetFeedLegacyPostsParams) error {
+	_, err := q.db.ExecContext(ctx, setFeedLegacyPosts, arg.ID, arg.HasLegacyPosts)
+	return err
+}
+
+const updateFeedCacheValidators = `-- name: UpdateFeedCacheValidators :exec
+UPDATE feeds
+SET etag = $2,
+    last_modified = $3,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = $1
+`
+
+type UpdateFeedCacheValidatorsParams struct {
+	ID           uuid.UUID
+	Etag         sql.NullString
+	LastModified sql.NullString
+}
+
+func (q *Queries) UpdateFeedCacheValidators(ctx context.Context, arg UpdateFeedCacheValidatorsParams) error {
+	_, err := q.db.ExecContext(ctx, updateFeedCacheValidators, arg.ID, arg.Etag, arg.LastModified)
+	return err
+}
+
+const updateFeedMetadata = `-- name: UpdateFeedMetadata :exec
+UPDATE feeds
+SET site_title = $2,
+    site_url = $3,
+    site_description = $4,
+    language = $5,
+    image_url = $6,
+    generator = $7,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = $1
+`
+
+type UpdateFeedMetadataParams struct {
+	ID              uuid.UUID
+	SiteTitle       sql.NullString
+	SiteUrl         sql.NullString
+	SiteDescription sql.NullString
+	Language        sql.NullString
+	ImageUrl        sql.NullString
+	Generator       sql.NullString
+}
+
+func (q *Queries) UpdateFeedMetadata(ctx context.Context, arg UpdateFeedMetadataParams) error {
+	_, err := q.db.ExecContext(ctx, updateFeedMetadata,
+		arg.ID,
+		arg.SiteTitle,
+		arg.SiteUrl,
+		arg.SiteDescription,
+		arg.Language,
+		arg.ImageUrl,
+		arg.Generator,
+	)
+	return err
+}
+
+const updateFeedURL = `-- name: UpdateFeedURL :exec
+UPDATE feeds
+SET url = $2,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = $1
+`
+
+type UpdateFeedURLParams struct {
+	ID  uuid.UUID
+	Url string
+}
+
+func (q *Queries) UpdateFeedURL(ctx context.Context, arg UpdateFeedURLParams) error {
+	_, err := q.db.ExecContext(ctx, updateFeedURL, arg.ID, arg.Url)
 	return err
 }
@@ -6,19 +6,103 @@ package database
 
 import (
 	"database/sql"
+	"database/sql/driver"
+	"fmt"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+type FeedStatus string
+
+const (
+	FeedStatusActive   FeedStatus = "active"
+	FeedStatusDegraded FeedStatus = "degraded"
+	FeedStatusDisabled FeedStatus = "disabled"
+)
+
+func (e *FeedStatus) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = FeedStatus(s)
+	case string:
+		*e = FeedStatus(s)
+	default:
+		return fmt.Errorf("unsupported scan type for FeedStatus: %T", src)
+	}
+	return nil
+}
+
+type NullFeedStatus struct {
+	FeedStatus FeedStatus
+	Valid      bool // Valid is true if FeedStatus is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullFeedStatus) Scan(value interface{}) error {
+	if value == nil {
+		ns.FeedStatus, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.FeedStatus.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullFeedStatus) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.FeedStatus), nil
+}
+
+type Enclosure struct {
+	ID        uuid.UUID
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	PostID    uuid.UUID
+	Url       string
+	MimeType  sql.NullString
+	Length    sql.NullInt64
+	Duration  sql.NullInt32
+	Episode   sql.NullInt32
+	ImageUrl  sql.NullString
+}
+
 type Feed struct {
-	ID            uuid.UUID
-	Name          string
-	Url           string
-	CreatedAt     time.Time
-	UpdatedAt     time.Time
-	UserID        uuid.UUID
-	LastFetchedAt sql.NullTime
+	ID                  uuid.UUID
+	Name                string
+	Url                 string
+	CreatedAt           time.Time
+	UpdatedAt           time.Time
+	UserID              uuid.UUID
+	LastFetchedAt       sql.NullTime
+	Etag                sql.NullString
+	LastModified        sql.NullString
+	NextFetchAt         sql.NullTime
+	FetchInterval       int32
+	SiteTitle           sql.NullString
+	SiteUrl             sql.NullString
+	SiteDescription     sql.NullString
+	Language            sql.NullString
+	ImageUrl            sql.NullString
+	Generator           sql.NullString
+	LastStatus          sql.NullInt32
+	LastError           sql.NullString
+	LastErrorAt         sql.NullTime
+	ConsecutiveFailures int32
+	Status              FeedStatus
+	DisabledReason      sql.NullString
+	HasLegacyPosts      bool
+}
+
+type FeedCredential struct {
+	ID        uuid.UUID
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	FeedID    uuid.UUID
+	Host      string
+	Secret    []byte
 }
 
 type FeedFollow struct {
@@ -29,15 +113,28 @@ type FeedFollow struct {
 	FeedID    uuid.UUID
 }
 
+type FeedUrlHistory struct {
+	ID        uuid.UUID
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	FeedID    uuid.UUID
+	Url       string
+}
+
 type Post struct {
-	ID          uuid.UUID
-	CreatedAt   time.Time
-	UpdatedAt   time.Time
-	Title       sql.NullString
-	Url         sql.NullString
-	Description sql.NullString
-	PublishedAt sql.NullTime
-	FeedID      uuid.UUID
+	ID                  uuid.UUID
+	CreatedAt           time.Time
+	UpdatedAt           time.Time
+	Title               sql.NullString
+	Url                 sql.NullString
+	Description         sql.NullString
+	PublishedAt         sql.NullTime
+	FeedID              uuid.UUID
+	Guid                string
+	Content             sql.NullString
+	Authors             []string
+	Categories          []string
+	PublishedAtInferred bool
 }
 
 type User struct {
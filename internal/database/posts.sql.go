@@ -10,67 +10,66 @@ import (
 	"database/sql"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
-const createPost = `-- name: CreatePost :exec
-INSERT INTO posts (
-        id,
-        title,
-        url,
-        description,
-        published_at,
-        feed_id
-    )
-VALUES ($1, $2, $3, $4, $5, $6)
-`
-
-type CreatePostParams struct {
-	ID          uuid.UUID
-	Title       sql.NullString
-	Url         sql.NullString
-	Description sql.NullString
-	PublishedAt sql.NullTime
-	FeedID      uuid.UUID
-}
-
-func (q *Queries) CreatePost(ctx context.Context, arg CreatePostParams) error {
-	_, err := q.db.ExecContext(ctx, createPost,
-		arg.ID,
-		arg.Title,
-		arg.Url,
-		arg.Description,
-		arg.PublishedAt,
-		arg.FeedID,
-	)
-	return err
-}
-
 const getPostsForUser = `-- name: GetPostsForUser :many
 SELECT posts.title,
     posts.url,
     posts.description,
-    posts.published_at
+    posts.published_at,
+    posts.published_at_inferred,
+    posts.content,
+    posts.authors,
+    posts.categories
 FROM posts
     INNER JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
 WHERE feed_follows.user_id = $1
+    AND (
+        $2::TEXT IS NULL
+        OR EXISTS (
+            SELECT 1
+            FROM unnest(posts.authors) AS author(name)
+            WHERE lower(author.name) = lower($2)
+        )
+    )
+    AND (
+        $3::TEXT IS NULL
+        OR EXISTS (
+            SELECT 1
+            FROM unnest(posts.categories) AS category(name)
+            WHERE lower(category.name) = lower($3)
+        )
+    )
 ORDER BY posts.published_at DESC
-LIMIT $2
+LIMIT $4
 `
 
 type GetPostsForUserParams struct {
-	UserID uuid.UUID
-	Limit  int32
+	UserID   uuid.UUID
+	Author   sql.NullString
+	Category sql.NullString
+	Limit    int32
 }
 
 type GetPostsForUserRow struct {
-	Title       sql.NullString
-	Url         sql.NullString
-	Description sql.NullString
-	PublishedAt sql.NullTime
+	Title               sql.NullString
+	Url                 sql.NullString
+	Description         sql.NullString
+	PublishedAt         sql.NullTime
+	PublishedAtInferred bool
+	Content             sql.NullString
+	Authors             []string
+	Categories          []string
 }
 
 func (q *Queries) GetPostsForUser(ctx context.Context, arg GetPostsForUserParams) ([]GetPostsForUserRow, error) {
-	rows, err := q.db.QueryContext(ctx, getPostsForUser, arg.UserID, arg.Limit)
+	rows, err := q.db.QueryContext(ctx, getPostsForUser,
+		arg.UserID,
+		arg.Author,
+		arg.Category,
+		arg.Limit,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -83,6 +82,10 @@ func (q *Queries) GetPostsForUser(ctx context.Context, arg GetPostsForUserParams
 			&i.Url,
 			&i.Description,
 			&i.PublishedAt,
+			&i.PublishedAtInferred,
+			&i.Content,
+			pq.Array(&i.Authors),
+			pq.Array(&i.Categories),
 		); err != nil {
 			return nil, err
 		}
@@ -96,3 +99,129 @@ func (q *Queries) GetPostsForUser(ctx context.Context, arg GetPostsForUserParams
 	}
 	return items, nil
 }
+
+const movePosts = `-- name: MovePosts :exec
+UPDATE posts
+SET feed_id = $1,
+    updated_at = CURRENT_TIMESTAMP
+WHERE posts.feed_id = $2
+    AND posts.guid NOT IN (
+        SELECT existing.guid
+        FROM posts AS existing
+        WHERE existing.feed_id = $1
+    )
+`
+
+type MovePostsParams struct {
+	ToFeedID   uuid.UUID
+	FromFeedID uuid.UUID
+}
+
+func (q *Queries) MovePosts(ctx context.Context, arg MovePostsParams) error {
+	_, err := q.db.ExecContext(ctx, movePosts, arg.ToFeedID, arg.FromFeedID)
+	return err
+}
+
+const rekeyLegacyPost = `-- name: RekeyLegacyPost :exec
+UPDATE posts
+SET guid = $1,
+    updated_at = CURRENT_TIMESTAMP
+WHERE posts.feed_id = $2
+    AND posts.guid = posts.url
+    AND posts.url = $3
+    AND NOT EXISTS (
+        SELECT 1
+        FROM posts AS existing
+        WHERE existing.feed_id = $2
+            AND existing.guid = $1
+    )
+`
+
+type RekeyLegacyPostParams struct {
+	Guid   string
+	FeedID uuid.UUID
+	Url    sql.NullString
+}
+
+func (q *Queries) RekeyLegacyPost(ctx context.Context, arg RekeyLegacyPostParams) error {
+	_, err := q.db.ExecContext(ctx, rekeyLegacyPost, arg.Guid, arg.FeedID, arg.Url)
+	return err
+}
+
+const upsertPost = `-- name: UpsertPost :one
+INSERT INTO posts (
+        id,
+        title,
+        url,
+        description,
+        published_at,
+        feed_id,
+        guid,
+        content,
+        authors,
+        categories,
+        published_at_inferred
+    )
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11) ON CONFLICT (feed_id, guid) DO
+UPDATE
+SET title = EXCLUDED.title,
+    url = EXCLUDED.url,
+    description = EXCLUDED.description,
+    published_at = CASE
+        WHEN EXCLUDED.published_at_inferred THEN posts.published_at
+        ELSE EXCLUDED.published_at
+    END,
+    published_at_inferred = posts.published_at_inferred
+    AND EXCLUDED.published_at_inferred,
+    content = EXCLUDED.content,
+    authors = EXCLUDED.authors,
+    categories = EXCLUDED.categories,
+    updated_at = CURRENT_TIMESTAMP
+WHERE posts.title IS DISTINCT FROM EXCLUDED.title
+    OR posts.url IS DISTINCT FROM EXCLUDED.url
+    OR posts.description IS DISTINCT FROM EXCLUDED.description
+    OR (
+        NOT EXCLUDED.published_at_inferred
+        AND (
+            posts.published_at IS DISTINCT FROM EXCLUDED.published_at
+            OR posts.published_at_inferred
+        )
+    )
+    OR posts.content IS DISTINCT FROM EXCLUDED.content
+    OR posts.authors IS DISTINCT FROM EXCLUDED.authors
+    OR posts.categories IS DISTINCT FROM EXCLUDED.categories
+RETURNING (xmax = 0) AS inserted
+`
+
+type UpsertPostParams struct {
+	ID                  uuid.UUID
+	Title               sql.NullString
+	Url                 sql.NullString
+	Description         sql.NullString
+	PublishedAt         sql.NullTime
+	FeedID              uuid.UUID
+	Guid                string
+	Content             sql.NullString
+	Authors             []string
+	Categories          []string
+	PublishedAtInferred bool
+}
+
+func (q *Queries) UpsertPost(ctx context.Context, arg UpsertPostParams) (bool, error) {
+	row := q.db.QueryRowContext(ctx, upsertPost,
+		arg.ID,
+		arg.Title,
+		arg.Url,
+		arg.Description,
+		arg.PublishedAt,
+		arg.FeedID,
+		arg.Guid,
+		arg.Content,
+		pq.Array(arg.Authors),
+		pq.Array(arg.Categories),
+		arg.PublishedAtInferred,
+	)
+	var inserted bool
+	err := row.Scan(&inserted)
+	return inserted, err
+}
@@ -0,0 +1,181 @@
+package opml
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Document represents an OPML 2.0 document, the format feed readers use to exchange subscription lists.
+type Document struct {
+	XMLName xml.Name `xml:"opml"`         // The root <opml> element
+	Version string   `xml:"version,attr"` // The OPML version, such as "2.0"
+	Head    Head     `xml:"head"`         // Metadata about the document
+	Body    Body     `xml:"body"`         // The outlines contained in the document
+}
+
+// Head holds the metadata of an OPML document.
+type Head struct {
+	Title       string `xml:"title,omitempty"`       // The title of the document
+	DateCreated string `xml:"dateCreated,omitempty"` // When the document was created, in RFC 822 format
+}
+
+// Body holds the top-level outlines of an OPML document.
+type Body struct {
+	Outlines []Outline `xml:"outline"` // The top-level outlines
+}
+
+// Outline represents an OPML <outline> element. Outlines with an xmlUrl are
+// subscriptions; outlines without one are folders grouping nested outlines.
+type Outline struct {
+	Text     string    `xml:"text,attr"`              // The text displayed for the outline
+	Title    string    `xml:"title,attr,omitempty"`   // The title of the feed
+	Type     string    `xml:"type,attr,omitempty"`    // The outline type, "rss" for subscriptions
+	XMLURL   string    `xml:"xmlUrl,attr,omitempty"`  // The URL of the feed
+	HTMLURL  string    `xml:"htmlUrl,attr,omitempty"` // The URL of the website the feed describes
+	Outlines []Outline `xml:"outline"`                // Nested outlines, when the outline is a folder
+}
+
+// Subscription is a single feed found in an OPML document.
+type Subscription struct {
+	Title   string // The title of the feed
+	XMLURL  string // The URL of the feed
+	HTMLURL string // The URL of the website the feed describes
+	Folder  string // The folder path containing the feed, with levels separated by "/"
+}
+
+// Parse reads an OPML document.
+//
+// Parameters:
+// - r: The reader to parse the document from.
+//
+// Returns:
+// - A pointer to the parsed Document.
+// - An error if the document is not valid OPML.
+func Parse(r io.Reader) (*Document, error) {
+	var doc Document
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("error unmarshaling OPML: %v", err)
+	}
+	return &doc, nil
+}
+
+// Subscriptions flattens the document's outline tree into a list of feeds.
+//
+// Returns:
+// - Every outline with a feed URL, in document order, along with the folder it was found in.
+func (d *Document) Subscriptions() []Subscription {
+	var subscriptions []Subscription
+	collect(d.Body.Outlines, "", &subscriptions)
+	return subscriptions
+}
+
+// collect walks a list of outlines depth-first and appends their subscriptions.
+//
+// Parameters:
+// - outlines: The outlines to walk.
+// - folder: The folder path of the outlines.
+// - subscriptions: The list the subscriptions are appended to.
+func collect(outlines []Outline, folder string, subscriptions *[]Subscription) {
+	for _, outline := range outlines {
+		title := strings.TrimSpace(outline.Title)
+		if title == "" {
+			title = strings.TrimSpace(outline.Text)
+		}
+		if feedURL := strings.TrimSpace(outline.XMLURL); feedURL != "" {
+			*subscriptions = append(*subscriptions, Subscription{
+				Title:   title,
+				XMLURL:  feedURL,
+				HTMLURL: strings.TrimSpace(outline.HTMLURL),
+				Folder:  folder,
+			})
+		}
+		// Any outline may contain children, but only folders normally do
+		if len(outline.Outlines) > 0 {
+			child := title
+			if folder != "" {
+				child = folder + "/" + title
+			}
+			collect(outline.Outlines, child, subscriptions)
+		}
+	}
+}
+
+// New builds an OPML 2.0 document from a list of subscriptions, grouping
+// subscriptions that have a folder into nested folder outlines.
+//
+// Parameters:
+// - title: The title of the document.
+// - subscriptions: The feeds to include, in the order they should appear.
+//
+// Returns:
+// - A pointer to the new Document.
+func New(title string, subscriptions []Subscription) *Document {
+	doc := &Document{
+		Version: "2.0",
+		Head:    Head{Title: title, DateCreated: time.Now().Format(time.RFC1123Z)},
+	}
+	for _, sub := range subscriptions {
+		outline := Outline{
+			Text:    sub.Title,
+			Title:   sub.Title,
+			Type:    "rss",
+			XMLURL:  sub.XMLURL,
+			HTMLURL: sub.HTMLURL,
+		}
+		var folders []string
+		if sub.Folder != "" {
+			folders = strings.Split(sub.Folder, "/")
+		}
+		insert(&doc.Body.Outlines, folders, outline)
+	}
+	return doc
+}
+
+// insert adds an outline below the given folder path, creating folders as needed.
+//
+// Parameters:
+// - outlines: The list of outlines at the current level.
+// - folders: The remaining folder path below the current level.
+// - outline: The subscription outline to add.
+func insert(outlines *[]Outline, folders []string, outline Outline) {
+	if len(folders) == 0 {
+		*outlines = append(*outlines, outline)
+		return
+	}
+	// Reuse the folder if it already exists at this level
+	for i := range *outlines {
+		folder := &(*outlines)[i]
+		if folder.XMLURL == "" && folder.Text == folders[0] {
+			insert(&folder.Outlines, folders[1:], outline)
+			return
+		}
+	}
+	*outlines = append(*outlines, Outline{Text: folders[0]})
+	insert(&(*outlines)[len(*outlines)-1].Outlines, folders[1:], outline)
+}
+
+// Write encodes the document as indented XML, preceded by an XML declaration.
+//
+// Parameters:
+// - w: The writer to encode the document to.
+//
+// Returns:
+// - An error if the document cannot be written.
+func (d *Document) Write(w io.Writer) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return fmt.Errorf("error writing OPML: %v", err)
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(d); err != nil {
+		return fmt.Errorf("error marshaling OPML: %v", err)
+	}
+	// Terminate the document with a newline, as encoding/xml does not
+	if _, err := io.WriteString(w, "\n"); err != nil {
+		return fmt.Errorf("error writing OPML: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,209 @@
+package render
+
+import (
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// droppedElements lists the elements that are removed together with their contents,
+// as they run code, embed other documents or hold no readable text.
+var droppedElements = map[atom.Atom]bool{
+	atom.Script: true, atom.Style: true, atom.Noscript: true, atom.Template: true,
+	atom.Iframe: true, atom.Frame: true, atom.Frameset: true, atom.Object: true,
+	atom.Embed: true, atom.Applet: true, atom.Form: true, atom.Input: true,
+	atom.Button: true, atom.Select: true, atom.Textarea: true, atom.Svg: true,
+	atom.Math: true, atom.Head: true, atom.Title: true, atom.Meta: true,
+	atom.Link: true, atom.Base: true, atom.Canvas: true,
+}
+
+// allowedAttributes maps each element that is kept to the attributes it may keep.
+// Elements missing from the map are unwrapped, keeping their contents.
+var allowedAttributes = map[atom.Atom][]string{
+	atom.A: {"href", "title"}, atom.Img: {"src", "alt", "title", "width", "height"},
+	atom.Div: nil, atom.Span: nil, atom.Section: nil, atom.Article: nil,
+	atom.P: nil, atom.Br: nil, atom.Hr: nil, atom.Blockquote: nil, atom.Pre: nil, atom.Code: nil,
+	atom.H1: nil, atom.H2: nil, atom.H3: nil, atom.H4: nil, atom.H5: nil, atom.H6: nil,
+	atom.Ul: nil, atom.Ol: {"start"}, atom.Li: nil, atom.Dl: nil, atom.Dt: nil, atom.Dd: nil,
+	atom.B: nil, atom.Strong: nil, atom.I: nil, atom.Em: nil, atom.U: nil, atom.S: nil,
+	atom.Del: nil, atom.Ins: nil, atom.Mark: nil, atom.Small: nil, atom.Sub: nil, atom.Sup: nil,
+	atom.Q: nil, atom.Cite: nil, atom.Abbr: {"title"}, atom.Kbd: nil, atom.Samp: nil, atom.Var: nil,
+	atom.Figure: nil, atom.Figcaption: nil, atom.Details: nil, atom.Summary: nil,
+	atom.Table: nil, atom.Caption: nil, atom.Thead: nil, atom.Tbody: nil, atom.Tfoot: nil,
+	atom.Tr: nil, atom.Th: {"colspan", "rowspan"}, atom.Td: {"colspan", "rowspan"},
+}
+
+// urlAttributes lists the attributes whose value is a URL and must use a safe scheme.
+var urlAttributes = map[string]bool{"href": true, "src": true}
+
+// safeSchemes lists the URL schemes that may appear in links and images.
+var safeSchemes = map[string]bool{"http": true, "https": true, "mailto": true}
+
+// Sanitize makes an HTML fragment from a feed safe to store and display. Scripts,
+// styles, embedded frames, forms and tracking pixels are removed, other elements are
+// limited to a small set of formatting tags and attributes, and links may only use
+// the http, https and mailto schemes.
+//
+// Parameters:
+// - fragment: The HTML fragment, such as the description of a feed item.
+//
+// Returns:
+// - The sanitized HTML, or the input unchanged if it contains no markup.
+func Sanitize(fragment string) string {
+	if !strings.Contains(fragment, "<") {
+		return fragment
+	}
+	nodes, err := parse(fragment)
+	if err != nil {
+		return ""
+	}
+	var out strings.Builder
+	for _, node := range nodes {
+		if err := html.Render(&out, node); err != nil {
+			return ""
+		}
+	}
+	return strings.TrimSpace(out.String())
+}
+
+// parse parses an HTML fragment and sanitizes the resulting nodes.
+//
+// Parameters:
+// - fragment: The HTML fragment.
+//
+// Returns:
+// - The sanitized top-level nodes of the fragment.
+// - An error if the fragment cannot be parsed.
+func parse(fragment string) ([]*html.Node, error) {
+	body := &html.Node{Type: html.ElementNode, Data: "body", DataAtom: atom.Body}
+	nodes, err := html.ParseFragment(strings.NewReader(fragment), body)
+	if err != nil {
+		return nil, err
+	}
+	for _, node := range nodes {
+		body.AppendChild(node)
+	}
+	sanitizeChildren(body)
+
+	nodes = nil
+	for child := body.FirstChild; child != nil; child = child.NextSibling {
+		nodes = append(nodes, child)
+	}
+	for _, node := range nodes {
+		body.RemoveChild(node)
+	}
+	return nodes, nil
+}
+
+// sanitizeChildren removes or unwraps the unsafe descendants of a node in place.
+//
+// Parameters:
+// - parent: The node whose children are sanitized.
+func sanitizeChildren(parent *html.Node) {
+	for child := parent.FirstChild; child != nil; {
+		next := child.NextSibling
+		switch child.Type {
+		case html.TextNode:
+			// Text is always kept, and escaped again when rendered.
+		case html.ElementNode:
+			attributes, allowed := allowedAttributes[child.DataAtom]
+			switch {
+			case droppedElements[child.DataAtom] || child.Namespace != "" || isTrackingPixel(child):
+				parent.RemoveChild(child)
+			case allowed:
+				child.Attr = cleanAttributes(child.Attr, attributes)
+				sanitizeChildren(child)
+			default:
+				// Keep the contents of unknown or purely presentational elements.
+				sanitizeChildren(child)
+				for grandchild := child.FirstChild; grandchild != nil; grandchild = child.FirstChild {
+					child.RemoveChild(grandchild)
+					parent.InsertBefore(grandchild, child)
+				}
+				parent.RemoveChild(child)
+			}
+		default:
+			// Comments and doctypes carry nothing worth keeping.
+			parent.RemoveChild(child)
+		}
+		child = next
+	}
+}
+
+// cleanAttributes keeps the allowed attributes of an element, dropping URLs with unsafe schemes.
+//
+// Parameters:
+// - attrs: The attributes of the element.
+// - allowed: The names of the attributes the element may keep.
+//
+// Returns:
+// - The attributes that are kept.
+func cleanAttributes(attrs []html.Attribute, allowed []string) []html.Attribute {
+	var kept []html.Attribute
+	for _, attr := range attrs {
+		if attr.Namespace != "" || !slices.Contains(allowed, attr.Key) {
+			continue
+		}
+		if urlAttributes[attr.Key] && !isSafeURL(attr.Val) {
+			continue
+		}
+		kept = append(kept, attr)
+	}
+	return kept
+}
+
+// isSafeURL reports whether a link or image URL uses a scheme that cannot run code.
+//
+// Parameters:
+// - value: The URL.
+//
+// Returns:
+// - true if the URL is relative or uses the http, https or mailto scheme.
+func isSafeURL(value string) bool {
+	parsed, err := url.Parse(strings.TrimSpace(value))
+	if err != nil {
+		return false
+	}
+	return parsed.Scheme == "" || safeSchemes[strings.ToLower(parsed.Scheme)]
+}
+
+// isTrackingPixel reports whether an element is an image too small to be seen,
+// which feeds embed to track who reads a post.
+//
+// Parameters:
+// - node: The element.
+//
+// Returns:
+// - true if the element is an image without a source or at most one pixel in size.
+func isTrackingPixel(node *html.Node) bool {
+	if node.DataAtom != atom.Img {
+		return false
+	}
+	if strings.TrimSpace(attribute(node, "src")) == "" {
+		return true
+	}
+	width, widthErr := strconv.Atoi(strings.TrimSuffix(attribute(node, "width"), "px"))
+	height, heightErr := strconv.Atoi(strings.TrimSuffix(attribute(node, "height"), "px"))
+	return widthErr == nil && heightErr == nil && width <= 1 && height <= 1
+}
+
+// attribute returns the value of an attribute on an element.
+//
+// Parameters:
+// - node: The element.
+// - name: The attribute name, in lower case.
+//
+// Returns:
+// - The attribute's value, or an empty string if it is not set.
+func attribute(node *html.Node, name string) string {
+	for _, attr := range node.Attr {
+		if attr.Namespace == "" && attr.Key == name {
+			return attr.Val
+		}
+	}
+	return ""
+}
@@ -0,0 +1,364 @@
+package render
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// blockElements lists the elements that start on a new line and are separated from
+// the surrounding text by a blank line.
+var blockElements = map[atom.Atom]bool{
+	atom.P: true, atom.Div: true, atom.Section: true, atom.Article: true,
+	atom.Figure: true, atom.Figcaption: true, atom.Details: true, atom.Summary: true,
+	atom.Dl: true, atom.Dt: true, atom.Dd: true, atom.Table: true, atom.Caption: true,
+}
+
+// headingLevels maps heading elements to their level.
+var headingLevels = map[atom.Atom]int{
+	atom.H1: 1, atom.H2: 2, atom.H3: 3, atom.H4: 4, atom.H5: 5, atom.H6: 6,
+}
+
+// list tracks the numbering of a list being rendered.
+type list struct {
+	ordered bool // Whether items are numbered rather than bulleted
+	next    int  // The number of the next item in an ordered list
+}
+
+// textRenderer converts sanitized HTML nodes into wrapped plain text.
+type textRenderer struct {
+	width  int             // The maximum line length, or 0 to disable wrapping
+	out    strings.Builder // The text of the finished blocks
+	inline strings.Builder // The text of the block being rendered
+	indent string          // The prefix of every line in the current block
+	marker string          // The list marker to put before the next block's first line
+	lists  []list          // The lists enclosing the current block, innermost last
+	links  []string        // The link targets shown as footnotes, in order
+}
+
+// Text converts an HTML fragment into plain text for display in a terminal.
+// Paragraphs are separated by blank lines, lists are bulleted or numbered, code
+// blocks are indented and links are numbered with their targets listed as footnotes.
+//
+// Parameters:
+// - fragment: The HTML fragment, such as the content of a post.
+// - width: The maximum line length, or 0 to disable wrapping.
+//
+// Returns:
+// - The rendered text, or the input wrapped to the width if it contains no markup.
+func Text(fragment string, width int) string {
+	r := &textRenderer{width: width}
+	if !strings.Contains(fragment, "<") {
+		// Plain text keeps its own line breaks. Sanitize escapes the entities of text it
+		// writes back out, so they are decoded even when no markup is left.
+		for _, line := range strings.Split(strings.TrimSpace(fragment), "\n") {
+			r.inline.WriteString(html.UnescapeString(line))
+			r.flush()
+		}
+		return StripControl(strings.TrimSpace(r.out.String()))
+	}
+
+	nodes, err := parse(fragment)
+	if err != nil {
+		return StripControl(strings.TrimSpace(fragment))
+	}
+	for _, node := range nodes {
+		r.render(node)
+	}
+	r.flush()
+
+	text := strings.TrimSpace(r.out.String())
+	if len(r.links) > 0 {
+		text += "\n"
+		for i, link := range r.links {
+			text += fmt.Sprintf("\n[%v] %v", i+1, link)
+		}
+	}
+	return StripControl(text)
+}
+
+// StripControl removes control characters other than newlines and tabs from text, so that
+// escape sequences embedded in a feed cannot take over the terminal it is displayed in.
+//
+// Parameters:
+// - text: The text to display.
+//
+// Returns:
+// - The text without C0 and C1 control characters, except newlines and tabs.
+func StripControl(text string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '\n' || r == '\t' {
+			return r
+		}
+		if r < 0x20 || (r >= 0x7f && r <= 0x9f) {
+			return -1
+		}
+		return r
+	}, text)
+}
+
+// render renders a node and its descendants.
+//
+// Parameters:
+// - node: The node to render.
+func (r *textRenderer) render(node *html.Node) {
+	if node.Type == html.TextNode {
+		r.text(node.Data)
+		return
+	}
+	if node.Type != html.ElementNode {
+		return
+	}
+
+	switch {
+	case node.DataAtom == atom.Br:
+		r.inline.WriteString("\n")
+	case node.DataAtom == atom.Hr:
+		r.flush()
+		r.blankLine()
+		r.inline.WriteString("---")
+		r.flush()
+		r.blankLine()
+	case node.DataAtom == atom.Pre:
+		r.flush()
+		r.blankLine()
+		r.preformatted(textContent(node))
+		r.blankLine()
+	case node.DataAtom == atom.Code:
+		r.inline.WriteString("`")
+		r.renderChildren(node)
+		r.inline.WriteString("`")
+	case node.DataAtom == atom.A:
+		r.renderChildren(node)
+		r.footnote(attribute(node, "href"), textContent(node))
+	case node.DataAtom == atom.Img:
+		if alt := strings.TrimSpace(attribute(node, "alt")); alt != "" {
+			r.text(fmt.Sprintf("[image: %v]", alt))
+		}
+	case node.DataAtom == atom.Blockquote:
+		r.flush()
+		r.blankLine()
+		indent := r.indent
+		r.indent += "> "
+		r.renderChildren(node)
+		r.flush()
+		r.indent = indent
+		r.blankLine()
+	case node.DataAtom == atom.Ul || node.DataAtom == atom.Ol:
+		r.flush()
+		if len(r.lists) == 0 {
+			r.blankLine()
+		}
+		r.lists = append(r.lists, list{ordered: node.DataAtom == atom.Ol, next: listStart(node)})
+		r.renderChildren(node)
+		r.flush()
+		r.lists = r.lists[:len(r.lists)-1]
+		if len(r.lists) == 0 {
+			r.blankLine()
+		}
+	case node.DataAtom == atom.Li:
+		r.listItem(node)
+	case node.DataAtom == atom.Tr:
+		r.flush()
+		r.renderChildren(node)
+		r.flush()
+	case node.DataAtom == atom.Td || node.DataAtom == atom.Th:
+		if node.PrevSibling != nil {
+			r.inline.WriteString(" | ")
+		}
+		r.renderChildren(node)
+	case headingLevels[node.DataAtom] > 0:
+		r.flush()
+		r.blankLine()
+		r.inline.WriteString(strings.Repeat("#", headingLevels[node.DataAtom]) + " ")
+		r.renderChildren(node)
+		r.flush()
+		r.blankLine()
+	case blockElements[node.DataAtom]:
+		r.flush()
+		if len(r.lists) == 0 {
+			r.blankLine()
+		}
+		r.renderChildren(node)
+		r.flush()
+		if len(r.lists) == 0 {
+			r.blankLine()
+		}
+	default:
+		r.renderChildren(node)
+	}
+}
+
+// renderChildren renders the children of a node in order.
+//
+// Parameters:
+// - node: The node whose children are rendered.
+func (r *textRenderer) renderChildren(node *html.Node) {
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		r.render(child)
+	}
+}
+
+// text adds inline text to the current block, collapsing whitespace as a browser would.
+//
+// Parameters:
+// - data: The text.
+func (r *textRenderer) text(data string) {
+	collapsed := strings.Join(strings.Fields(data), " ")
+	pending := r.inline.String()
+	startsWithSpace := data != "" && strings.TrimLeft(data, " \t\r\n\f") != data
+	endsWithSpace := strings.TrimRight(data, " \t\r\n\f") != data
+
+	if startsWithSpace && pending != "" && !strings.HasSuffix(pending, " ") && !strings.HasSuffix(pending, "\n") {
+		r.inline.WriteString(" ")
+	}
+	r.inline.WriteString(collapsed)
+	if endsWithSpace && collapsed != "" {
+		r.inline.WriteString(" ")
+	}
+}
+
+// footnote numbers a link and records its target, unless the link text already shows it.
+//
+// Parameters:
+// - href: The target of the link.
+// - label: The text of the link.
+func (r *textRenderer) footnote(href, label string) {
+	href = strings.TrimSpace(href)
+	if href == "" || strings.HasPrefix(href, "#") || strings.TrimSpace(label) == href {
+		return
+	}
+	r.links = append(r.links, href)
+	pending := strings.TrimRight(r.inline.String(), " ")
+	r.inline.Reset()
+	r.inline.WriteString(fmt.Sprintf("%v[%v]", pending, len(r.links)))
+}
+
+// listItem renders a list item behind a bullet or its number, indenting
+// continuation lines and nested lists to line up with the item's text.
+//
+// Parameters:
+// - node: The <li> element.
+func (r *textRenderer) listItem(node *html.Node) {
+	r.flush()
+	marker := "- "
+	if len(r.lists) > 0 && r.lists[len(r.lists)-1].ordered {
+		current := &r.lists[len(r.lists)-1]
+		marker = fmt.Sprintf("%v. ", current.next)
+		current.next++
+	}
+	indent := r.indent
+	r.marker = marker
+	r.renderChildren(node)
+	r.flush()
+	r.indent = indent
+	r.marker = ""
+}
+
+// preformatted adds a code block, keeping its line breaks and indenting it by four spaces.
+//
+// Parameters:
+// - code: The text of the block.
+func (r *textRenderer) preformatted(code string) {
+	code = strings.Trim(code, "\n")
+	for _, line := range strings.Split(code, "\n") {
+		r.out.WriteString(strings.TrimRight(r.indent+"    "+line, " ") + "\n")
+	}
+}
+
+// flush wraps the pending inline text and adds it to the output as a block.
+func (r *textRenderer) flush() {
+	pending := r.inline.String()
+	r.inline.Reset()
+	if strings.TrimSpace(pending) == "" {
+		return
+	}
+
+	first := r.indent + r.marker
+	rest := r.indent + strings.Repeat(" ", utf8.RuneCountInString(r.marker))
+	prefix := first
+	for _, line := range strings.Split(pending, "\n") {
+		for _, wrapped := range wrap(strings.TrimSpace(line), r.width-utf8.RuneCountInString(prefix)) {
+			r.out.WriteString(strings.TrimRight(prefix+wrapped, " ") + "\n")
+			prefix = rest
+		}
+	}
+
+	// Nested blocks of the same item line up with the text after its marker.
+	if r.marker != "" {
+		r.indent = rest
+		r.marker = ""
+	}
+}
+
+// blankLine separates the next block from the previous one, without doubling blank lines.
+func (r *textRenderer) blankLine() {
+	out := r.out.String()
+	if out != "" && !strings.HasSuffix(out, "\n\n") {
+		r.out.WriteString("\n")
+	}
+}
+
+// wrap splits a line of text into lines no longer than the given width,
+// breaking between words. Words longer than the width are kept whole.
+//
+// Parameters:
+// - line: The text to wrap.
+// - width: The maximum line length; wrapping is disabled if it is not positive.
+//
+// Returns:
+// - The wrapped lines.
+func wrap(line string, width int) []string {
+	words := strings.Fields(line)
+	if width <= 0 || len(words) == 0 {
+		return []string{line}
+	}
+	var lines []string
+	current := words[0]
+	for _, word := range words[1:] {
+		if utf8.RuneCountInString(current)+1+utf8.RuneCountInString(word) > width {
+			lines = append(lines, current)
+			current = word
+			continue
+		}
+		current += " " + word
+	}
+	return append(lines, current)
+}
+
+// listStart returns the number of the first item of a list.
+//
+// Parameters:
+// - node: The <ul> or <ol> element.
+//
+// Returns:
+// - The value of the list's start attribute, or 1.
+func listStart(node *html.Node) int {
+	start, err := strconv.Atoi(strings.TrimSpace(attribute(node, "start")))
+	if err != nil {
+		return 1
+	}
+	return start
+}
+
+// textContent returns the text of a node and its descendants.
+//
+// Parameters:
+// - node: The node.
+//
+// Returns:
+// - The concatenated text of every text node below the node.
+func textContent(node *html.Node) string {
+	if node.Type == html.TextNode {
+		return node.Data
+	}
+	var text strings.Builder
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		text.WriteString(textContent(child))
+	}
+	return text.String()
+}
@@ -0,0 +1,240 @@
+package rss
+
+import (
+	"encoding/xml"
+	"html"
+	"strings"
+)
+
+// atomNamespace is the XML namespace that identifies an Atom 1.0 document.
+const atomNamespace = "http://www.w3.org/2005/Atom"
+
+// atomFeed represents the structure of an Atom 1.0 feed parsed from XML.
+type atomFeed struct {
+	Title     atomText     `xml:"title"`                                          // The title of the feed
+	Subtitle  atomText     `xml:"subtitle"`                                       // A short description of the feed
+	Icon      string       `xml:"icon"`                                           // The URL of a small icon for the feed
+	Logo      string       `xml:"logo"`                                           // The URL of a larger logo for the feed
+	Generator string       `xml:"generator"`                                      // The software that generated the feed
+	Lang      string       `xml:"http://www.w3.org/XML/1998/namespace lang,attr"` // The language of the feed
+	XMLBase   string       `xml:"http://www.w3.org/XML/1998/namespace base,attr"` // The base URL of the feed's relative links
+	Authors   []atomPerson `xml:"author"`                                         // The feed's authors, used for entries without their own
+	Links     []atomLink   `xml:"link"`                                           // Links related to the feed (site, self, hub, ...)
+	Entries   []atomEntry  `xml:"entry"`                                          // A list of entries (posts) in the feed
+}
+
+// atomEntry represents an individual entry (post) in an Atom feed.
+type atomEntry struct {
+	ID         string         `xml:"id"`                                             // The permanent, universally unique identifier of the entry
+	Title      atomText       `xml:"title"`                                          // The title of the entry
+	Links      []atomLink     `xml:"link"`                                           // Links related to the entry
+	Summary    atomText       `xml:"summary"`                                        // A short summary of the entry
+	Content    atomText       `xml:"content"`                                        // The full content of the entry
+	Published  string         `xml:"published"`                                      // When the entry was first published
+	Updated    string         `xml:"updated"`                                        // When the entry was last modified
+	Authors    []atomPerson   `xml:"author"`                                         // The authors of the entry
+	Categories []atomCategory `xml:"category"`                                       // The categories of the entry
+	XMLBase    string         `xml:"http://www.w3.org/XML/1998/namespace base,attr"` // The base URL of the entry's relative links
+}
+
+// atomPerson represents an Atom person construct, such as an <author>.
+type atomPerson struct {
+	Name string `xml:"name"` // The person's name
+}
+
+// atomCategory represents an Atom <category> element.
+type atomCategory struct {
+	Term  string `xml:"term,attr"`  // The identifier of the category
+	Label string `xml:"label,attr"` // A human-readable name for the category
+}
+
+// atomLink represents an Atom <link> element.
+type atomLink struct {
+	Href   string `xml:"href,attr"`   // The target of the link
+	Rel    string `xml:"rel,attr"`    // The relation type; an empty value means "alternate"
+	Type   string `xml:"type,attr"`   // The advertised media type of the target
+	Length string `xml:"length,attr"` // The advertised size of the target in bytes
+}
+
+// atomText represents an Atom text construct, which may hold plain text,
+// escaped HTML or inline XHTML depending on its type attribute.
+type atomText struct {
+	Type  string // One of "text", "html" or "xhtml"
+	Text  string // The unescaped character data
+	Inner string // The markup of the child elements, used for XHTML content
+}
+
+// voidElements lists the XHTML elements that have no end tag when written as HTML.
+var voidElements = map[string]bool{
+	"area": true, "base": true, "br": true, "col": true, "embed": true, "hr": true,
+	"img": true, "input": true, "link": true, "meta": true, "source": true, "track": true, "wbr": true,
+}
+
+// UnmarshalXML decodes a text construct, rebuilding the markup of XHTML content from its
+// tokens. The markup cannot be captured with ",innerxml", which stays empty when the
+// document is decoded from a token stream such as the one that limits the number of items.
+func (t *atomText) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	for _, attr := range start.Attr {
+		if attr.Name.Space == "" && attr.Name.Local == "type" {
+			t.Type = attr.Value
+		}
+	}
+
+	var text, inner strings.Builder
+	depth := 0
+	for {
+		token, err := d.Token()
+		if err != nil {
+			return err
+		}
+		switch tok := token.(type) {
+		case xml.StartElement:
+			depth++
+			inner.WriteString("<" + tok.Name.Local)
+			for _, attr := range tok.Attr {
+				// Namespace declarations and namespaced attributes mean nothing in HTML
+				if attr.Name.Space != "" || attr.Name.Local == "xmlns" {
+					continue
+				}
+				inner.WriteString(" " + attr.Name.Local + `="` + html.EscapeString(attr.Value) + `"`)
+			}
+			inner.WriteString(">")
+		case xml.EndElement:
+			if depth == 0 {
+				t.Text = text.String()
+				t.Inner = inner.String()
+				return nil
+			}
+			depth--
+			if !voidElements[tok.Name.Local] {
+				inner.WriteString("</" + tok.Name.Local + ">")
+			}
+		case xml.CharData:
+			if depth == 0 {
+				text.Write(tok)
+			}
+			inner.WriteString(html.EscapeString(string(tok)))
+		}
+	}
+}
+
+// String returns the textual value of the construct. XHTML content is
+// returned as markup with its wrapping <div> removed.
+func (t atomText) String() string {
+	if t.Type != "xhtml" {
+		return strings.TrimSpace(t.Text)
+	}
+	inner := strings.TrimSpace(t.Inner)
+	// Strip the mandatory <div xmlns="http://www.w3.org/1999/xhtml"> wrapper.
+	start := strings.Index(inner, ">")
+	end := strings.LastIndex(inner, "</")
+	if strings.HasPrefix(inner, "<div") && start != -1 && end > start {
+		inner = inner[start+1 : end]
+	}
+	return strings.TrimSpace(inner)
+}
+
+// alternateLink picks the most suitable "alternate" link from a list of Atom links.
+//
+// Parameters:
+// - links: The links attached to a feed or entry.
+//
+// Returns:
+// - The href of the preferred alternate link, or an empty string if there is none.
+func alternateLink(links []atomLink) string {
+	var fallback string
+	for _, link := range links {
+		if link.Rel != "" && link.Rel != "alternate" {
+			continue
+		}
+		// Prefer an HTML page over other alternate representations.
+		if link.Type == "" || link.Type == "text/html" {
+			return link.Href
+		}
+		if fallback == "" {
+			fallback = link.Href
+		}
+	}
+	return fallback
+}
+
+// personNames collects the names of a list of Atom persons.
+//
+// Parameters:
+// - persons: The person constructs.
+//
+// Returns:
+// - The names of the persons that have one.
+func personNames(persons []atomPerson) []string {
+	var names []string
+	for _, person := range persons {
+		if name := strings.TrimSpace(person.Name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// enclosureLinks collects the rel="enclosure" links of an Atom entry.
+//
+// Parameters:
+// - links: The links attached to an entry.
+//
+// Returns:
+// - The entry's enclosures, in document order.
+func enclosureLinks(links []atomLink) []RSSEnclosure {
+	var enclosures []RSSEnclosure
+	for _, link := range links {
+		if link.Rel == "enclosure" {
+			enclosures = append(enclosures, RSSEnclosure{URL: link.Href, Type: link.Type, Length: link.Length})
+		}
+	}
+	return enclosures
+}
+
+// toRSS maps an Atom feed onto the normalized RSSFeed model.
+//
+// Returns:
+// - A pointer to an RSSFeed containing the feed's metadata and entries.
+func (a *atomFeed) toRSS() *RSSFeed {
+	var feed RSSFeed
+	feed.Channel.XMLBase = a.XMLBase
+	feed.Channel.Title = a.Title.String()
+	feed.Channel.Link = alternateLink(a.Links)
+	feed.Channel.Description = a.Subtitle.String()
+	feed.Channel.Language = strings.TrimSpace(a.Lang)
+	feed.Channel.Generator = strings.TrimSpace(a.Generator)
+	feed.Channel.Image.URL = firstNonEmpty(a.Logo, a.Icon)
+	feedAuthors := personNames(a.Authors)
+
+	for _, entry := range a.Entries {
+		// Use the publication date, or the last update if it was never published.
+		pubDate := strings.TrimSpace(entry.Published)
+		if pubDate == "" {
+			pubDate = strings.TrimSpace(entry.Updated)
+		}
+		// Entries without authors inherit those of the feed.
+		authors := personNames(entry.Authors)
+		if len(authors) == 0 {
+			authors = feedAuthors
+		}
+		// Prefer the human-readable label of a category over its term.
+		var categories []string
+		for _, category := range entry.Categories {
+			categories = append(categories, firstNonEmpty(category.Label, category.Term))
+		}
+		feed.Channel.Item = append(feed.Channel.Item, RSSItem{
+			Title:       entry.Title.String(),
+			Link:        alternateLink(entry.Links),
+			Description: entry.Summary.String(),
+			PubDate:     pubDate,
+			GUID:        RSSGUID{Value: strings.TrimSpace(entry.ID), IsPermaLink: "false"},
+			Content:     entry.Content.String(),
+			Enclosures:  enclosureLinks(entry.Links),
+			Authors:     authors,
+			Categories:  categories,
+			XMLBase:     entry.XMLBase,
+		})
+	}
+	return &feed
+}
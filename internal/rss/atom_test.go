@@ -0,0 +1,47 @@
+package rss
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseFeedAtomXHTML(t *testing.T) {
+	const feed = `<?xml version="1.0" encoding="utf-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom">
+  <title type="xhtml"><div xmlns="http://www.w3.org/1999/xhtml">Feed <em>title</em></div></title>
+  <entry>
+    <id>urn:entry:1</id>
+    <title>Plain &amp; simple</title>
+    <summary type="html">&lt;b&gt;Bold&lt;/b&gt;</summary>
+    <content type="xhtml">
+      <div xmlns="http://www.w3.org/1999/xhtml"><p>Hi &amp; welcome</p><br/><a href="/about" xml:lang="en">About</a></div>
+    </content>
+  </entry>
+</feed>`
+
+	fetcher, err := NewFetcher(FetcherConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := fetcher.parseFeed("application/atom+xml", strings.NewReader(feed))
+	if err != nil {
+		t.Fatalf("parseFeed: %v", err)
+	}
+
+	if got, want := parsed.Channel.Title, "Feed <em>title</em>"; got != want {
+		t.Errorf("feed title = %q, want %q", got, want)
+	}
+	if len(parsed.Channel.Item) != 1 {
+		t.Fatalf("got %d items, want 1", len(parsed.Channel.Item))
+	}
+	item := parsed.Channel.Item[0]
+	if got, want := item.Title, "Plain & simple"; got != want {
+		t.Errorf("title = %q, want %q", got, want)
+	}
+	if got, want := item.Description, "<b>Bold</b>"; got != want {
+		t.Errorf("summary = %q, want %q", got, want)
+	}
+	if got, want := item.Content, `<p>Hi &amp; welcome</p><br><a href="/about">About</a>`; got != want {
+		t.Errorf("content = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,61 @@
+package rss
+
+import (
+	"strings"
+)
+
+// authorName extracts a display name from an RSS <author> value, which the
+// specification defines as an email address optionally followed by a name in
+// parentheses, e.g. "jdoe@example.com (John Doe)".
+//
+// Parameters:
+// - value: The raw author value.
+//
+// Returns:
+// - The author's name if one is given in parentheses, otherwise the trimmed value.
+func authorName(value string) string {
+	value = strings.TrimSpace(value)
+	open := strings.Index(value, "(")
+	end := strings.LastIndex(value, ")")
+	if open != -1 && end > open && strings.Contains(value[:open], "@") {
+		if name := strings.TrimSpace(value[open+1 : end]); name != "" {
+			return name
+		}
+	}
+	return value
+}
+
+// uniqueStrings trims a list of values and removes blanks and case-insensitive duplicates.
+//
+// Parameters:
+// - values: The values to clean up.
+//
+// Returns:
+// - The remaining values in their original order.
+func uniqueStrings(values []string) []string {
+	var unique []string
+	seen := make(map[string]bool)
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		key := strings.ToLower(value)
+		if value == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		unique = append(unique, value)
+	}
+	return unique
+}
+
+// normalizeTaxonomy merges the item's Dublin Core creators into its authors, extracts
+// author names from RSS email-style values and removes duplicate authors and categories.
+func (item *RSSItem) normalizeTaxonomy() {
+	authors := make([]string, 0, len(item.Authors)+len(item.DCCreators))
+	for _, author := range item.Authors {
+		authors = append(authors, authorName(author))
+	}
+	authors = append(authors, item.DCCreators...)
+	item.Authors = uniqueStrings(authors)
+	item.DCCreators = nil
+	item.Categories = uniqueStrings(item.Categories)
+}
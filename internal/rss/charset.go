@@ -0,0 +1,60 @@
+package rss
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"mime"
+	"strings"
+
+	"golang.org/x/net/html/charset"
+)
+
+// newXMLDecoder creates an XML decoder that converts a feed document to UTF-8.
+// A charset given in the Content-Type header takes precedence over the encoding
+// named in the document's XML declaration, as the specification requires.
+//
+// Parameters:
+// - contentType: The Content-Type header sent with the document.
+// - input: The raw XML document.
+//
+// Returns:
+// - An xml.Decoder that reads the document as UTF-8.
+func newXMLDecoder(contentType string, input io.Reader) *xml.Decoder {
+	// Transcode the whole document up front when the server names its charset.
+	transcoded := false
+	if label := contentTypeCharset(contentType); label != "" {
+		if encoding, _ := charset.Lookup(label); encoding != nil {
+			input = encoding.NewDecoder().Reader(input)
+			transcoded = true
+		}
+	}
+
+	decoder := xml.NewDecoder(input)
+	decoder.CharsetReader = func(label string, input io.Reader) (io.Reader, error) {
+		if transcoded {
+			return input, nil // Already UTF-8, whatever the declaration says.
+		}
+		reader, err := charset.NewReaderLabel(label, input)
+		if err != nil {
+			return nil, fmt.Errorf("unsupported encoding %q: %v", label, err)
+		}
+		return reader, nil
+	}
+	return decoder
+}
+
+// contentTypeCharset extracts the charset parameter from a Content-Type header.
+//
+// Parameters:
+// - contentType: The Content-Type header.
+//
+// Returns:
+// - The lowercase charset label, or an empty string if there is none.
+func contentTypeCharset(contentType string) string {
+	_, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(strings.TrimSpace(params["charset"]))
+}
@@ -0,0 +1,55 @@
+package rss
+
+import (
+	"context"
+	"net/http"
+	"strings"
+)
+
+// Credentials authenticate requests for a private feed, with either HTTP Basic auth or a bearer token.
+type Credentials struct {
+	Host     string // The host (and port, if any) the credentials may be sent to
+	Username string // The user name for Basic auth
+	Password string // The password for Basic auth
+	Token    string // The bearer token; used instead of Basic auth when set
+}
+
+// credentialsKey is the context key the credentials of a fetch are stored under.
+type credentialsKey struct{}
+
+// WithCredentials returns a context whose fetches authenticate with the given credentials.
+// They are only attached to https requests for the credentials' host, including after redirects.
+//
+// Parameters:
+// - ctx: The parent context.
+// - credentials: The credentials of the feed being fetched.
+//
+// Returns:
+// - A context carrying the credentials.
+func WithCredentials(ctx context.Context, credentials Credentials) context.Context {
+	return context.WithValue(ctx, credentialsKey{}, credentials)
+}
+
+// authTransport is an http.RoundTripper that adds the credentials found in a request's
+// context, but only when the request is for the host they belong to and is encrypted.
+type authTransport struct {
+	base http.RoundTripper // The transport that sends the requests
+}
+
+// RoundTrip sends a request, authenticating it if its context carries credentials for its host.
+// Credentials are never sent over plain http, not even after a redirect on the same host.
+func (t *authTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	credentials, ok := req.Context().Value(credentialsKey{}).(Credentials)
+	if !ok || credentials.Host == "" || req.URL.Scheme != "https" || !strings.EqualFold(req.URL.Host, credentials.Host) {
+		return t.base.RoundTrip(req)
+	}
+
+	// A RoundTripper must not modify the request it was given
+	req = req.Clone(req.Context())
+	if credentials.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+credentials.Token)
+	} else {
+		req.SetBasicAuth(credentials.Username, credentials.Password)
+	}
+	return t.base.RoundTrip(req)
+}
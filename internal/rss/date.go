@@ -0,0 +1,181 @@
+package rss
+
+import (
+	"strings"
+	"time"
+)
+
+// dateLayouts lists the date formats found in real-world feeds, tried in order after
+// ParseDate has stripped the weekday and replaced named time zones with offsets.
+// Day numbers may have one or two digits and seconds may carry a fraction.
+var dateLayouts = []string{
+	// RFC 822 and RFC 1123, as used by RSS, with four or two-digit years
+	"2 Jan 2006 15:04:05 -0700",
+	"2 Jan 2006 15:04:05 -07:00",
+	"2 Jan 2006 15:04:05 MST",
+	"2 Jan 2006 15:04:05",
+	"2 Jan 2006 15:04 -0700",
+	"2 Jan 2006 15:04 MST",
+	"2 Jan 2006 15:04",
+	"2 Jan 06 15:04:05 -0700",
+	"2 Jan 06 15:04:05 MST",
+	"2 Jan 06 15:04:05",
+	"2 Jan 06 15:04 -0700",
+	"2 Jan 06 15:04 MST",
+	"2 Jan 2006",
+
+	// Full month names and US-style month-first dates
+	"2 January 2006 15:04:05 -0700",
+	"2 January 2006 15:04:05 MST",
+	"2 January 2006 15:04:05",
+	"2 January 2006",
+	"January 2, 2006 15:04:05 -0700",
+	"January 2, 2006 15:04:05 MST",
+	"January 2, 2006 15:04:05",
+	"January 2, 2006",
+	"Jan 2, 2006 15:04:05 -0700",
+	"Jan 2, 2006 15:04:05 MST",
+	"Jan 2, 2006 15:04:05",
+	"Jan 2, 2006",
+	"Jan 2 2006 15:04:05 -0700",
+	"Jan 2 2006 15:04:05",
+
+	// ANSI C and Unix date output
+	"Jan 2 15:04:05 -0700 2006",
+	"Jan 2 15:04:05 MST 2006",
+	"Jan 2 15:04:05 2006",
+
+	// ISO 8601 and RFC 3339 variants
+	time.RFC3339,
+	"2006-01-02T15:04:05Z0700",
+	"2006-01-02T15:04:05-07",
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04Z07:00",
+	"2006-01-02T15:04Z0700",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04:05Z07:00",
+	"2006-01-02 15:04:05 -0700",
+	"2006-01-02 15:04:05 -07:00",
+	"2006-01-02 15:04:05 MST",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"20060102T150405Z0700",
+	"20060102T150405",
+	"2006-01-02",
+	"2006/01/02 15:04:05",
+	"2006/01/02",
+}
+
+// zoneOffsets maps time zone abbreviations commonly found in feeds to their UTC offsets,
+// as the standard library cannot resolve them on its own.
+var zoneOffsets = map[string]string{
+	"UT": "+0000", "UTC": "+0000", "GMT": "+0000", "Z": "+0000", "WET": "+0000",
+	"BST": "+0100", "IST": "+0530", "CET": "+0100", "MET": "+0100", "WEST": "+0100",
+	"CEST": "+0200", "MEST": "+0200", "EET": "+0200", "SAST": "+0200",
+	"EEST": "+0300", "MSK": "+0300",
+	"PKT": "+0500", "ICT": "+0700", "WIB": "+0700",
+	"HKT": "+0800", "SGT": "+0800", "AWST": "+0800", "PHT": "+0800",
+	"JST": "+0900", "KST": "+0900",
+	"ACST": "+0930", "ACDT": "+1030", "AEST": "+1000", "AEDT": "+1100",
+	"NZST": "+1200", "NZDT": "+1300",
+	"NST": "-0330", "NDT": "-0230",
+	"AST": "-0400", "ADT": "-0300",
+	"EST": "-0500", "EDT": "-0400",
+	"CST": "-0600", "CDT": "-0500",
+	"MST": "-0700", "MDT": "-0600",
+	"PST": "-0800", "PDT": "-0700",
+	"AKST": "-0900", "AKDT": "-0800",
+	"HST": "-1000",
+}
+
+// weekdays holds the lowercase prefixes of weekday names, which are dropped before
+// parsing as feeds often pair them with the wrong date or abbreviate them oddly.
+var weekdays = []string{"mon", "tue", "wed", "thu", "fri", "sat", "sun"}
+
+// ParseDate parses a publication date in any of the many formats found in feeds.
+//
+// Parameters:
+// - value: The date string, such as the value of a <pubDate> element.
+//
+// Returns:
+// - The parsed time.
+// - false if the value is empty or does not match any known format.
+func ParseDate(value string) (time.Time, bool) {
+	value = normalizeDate(value)
+	if value == "" {
+		return time.Time{}, false
+	}
+	for _, layout := range dateLayouts {
+		if parsed, err := time.Parse(layout, value); err == nil {
+			return parsed, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// normalizeDate rewrites a date string into a form matched by dateLayouts: it collapses
+// whitespace, drops a leading weekday and trailing comments in parentheses, and
+// replaces known time zone abbreviations with their numeric offsets.
+//
+// Parameters:
+// - value: The raw date string.
+//
+// Returns:
+// - The normalized date string.
+func normalizeDate(value string) string {
+	// Drop comments such as the "(PST)" in "-0800 (PST)".
+	if open := strings.Index(value, "("); open != -1 {
+		value = value[:open]
+	}
+	fields := strings.Fields(value)
+	if len(fields) == 0 {
+		return ""
+	}
+
+	// Drop the weekday, which may be abbreviated, spelled out or followed by a comma.
+	first := strings.ToLower(strings.TrimSuffix(fields[0], ","))
+	for _, weekday := range weekdays {
+		if strings.HasPrefix(first, weekday) && !strings.ContainsAny(first, "0123456789") {
+			fields = fields[1:]
+			break
+		}
+	}
+	if len(fields) == 0 {
+		return ""
+	}
+
+	// Resolve named time zones, which Unix dates place before the year, including the
+	// "UTC" in "UTC+2" style values.
+	for i, field := range fields {
+		if offset, ok := zoneOffsets[strings.ToUpper(field)]; ok {
+			fields[i] = offset
+		} else if sign := strings.IndexAny(field, "+-"); sign > 0 {
+			if _, ok := zoneOffsets[strings.ToUpper(field[:sign])]; ok {
+				fields[i] = zoneOffset(field[sign:])
+			}
+		}
+	}
+	return strings.Join(fields, " ")
+}
+
+// zoneOffset expands a short offset such as "+2" or "-5:30" into the "-0700" form.
+//
+// Parameters:
+// - offset: The signed offset.
+//
+// Returns:
+// - The offset as a sign followed by four digits, or the input if it cannot be expanded.
+func zoneOffset(offset string) string {
+	sign, rest := offset[:1], strings.ReplaceAll(offset[1:], ":", "")
+	switch len(rest) {
+	case 1:
+		return sign + "0" + rest + "00"
+	case 2:
+		return sign + rest + "00"
+	case 3:
+		return sign + "0" + rest
+	case 4:
+		return sign + rest
+	}
+	return offset
+}
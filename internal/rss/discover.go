@@ -0,0 +1,221 @@
+package rss
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// ErrHTMLPage is returned when a URL points to a web page rather than a feed.
+var ErrHTMLPage = errors.New("document is an HTML page, not a feed")
+
+// feedMediaTypes lists the media types that identify a feed in a <link rel="alternate"> tag.
+var feedMediaTypes = map[string]bool{
+	"application/rss+xml":   true,
+	"application/atom+xml":  true,
+	"application/feed+json": true,
+	"application/rdf+xml":   true,
+}
+
+// commonFeedPaths lists well-known feed locations tried when a page advertises no feeds.
+var commonFeedPaths = []string{
+	"/feed",
+	"/rss.xml",
+	"/atom.xml",
+	"/feed.xml",
+	"/index.xml",
+	"/feed.json",
+	"/rss",
+}
+
+// isHTML reports whether a document is an HTML page. Leading comments are skipped first,
+// as content sniffing takes any document that starts with a comment, feeds included, for HTML.
+//
+// Parameters:
+// - data: The start of the raw document.
+//
+// Returns:
+// - true if the document content after any leading comments sniffs as HTML.
+func isHTML(data []byte) bool {
+	data = bytes.TrimPrefix(data, utf8BOM)
+	for {
+		data = bytes.TrimLeft(data, " \t\r\n\f")
+		if !bytes.HasPrefix(data, []byte("<!--")) {
+			break
+		}
+		end := bytes.Index(data, []byte("-->"))
+		if end == -1 {
+			return false // The comment runs past the sniffed bytes; the root element is checked when parsing.
+		}
+		data = data[end+len("-->"):]
+	}
+	return strings.HasPrefix(http.DetectContentType(data), "text/html")
+}
+
+// DiscoverFeeds finds the feeds available at a URL. If the URL already points to a
+// feed it is returned as-is; if it points to a web page, the feeds advertised by the
+// page's <link rel="alternate"> tags are returned, falling back to common feed paths.
+//
+// Parameters:
+// - ctx: A context for managing request cancellation and timeouts.
+// - pageURL: The URL of a feed or of a web page.
+//
+// Returns:
+// - A list of absolute feed URLs, containing at least one entry.
+// - An error if the URL cannot be fetched or no feed can be found.
+func (f *Fetcher) DiscoverFeeds(ctx context.Context, pageURL string) ([]string, error) {
+	base, err := url.Parse(pageURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid URL: %v", err)
+	}
+
+	doc, err := f.fetchDocument(ctx, pageURL, CacheValidators{})
+	if err != nil {
+		return nil, err
+	}
+
+	// Keep the page in memory, as it is read again to look for feed links
+	data, err := io.ReadAll(doc.Body)
+	doc.Body.Close()
+	if err != nil {
+		return nil, decodeError("cannot read page", err)
+	}
+
+	// The URL is already a feed, so there is nothing to discover other than where it moved to
+	_, err = f.parseFeed(doc.ContentType, bytes.NewReader(data))
+	if err == nil {
+		if doc.MovedTo != "" {
+			return []string{doc.MovedTo}, nil
+		}
+		return []string{pageURL}, nil
+	}
+	if !errors.Is(err, ErrHTMLPage) {
+		return nil, err
+	}
+
+	// Look for feeds advertised by the page itself, relative to where it was served from
+	if served, err := url.Parse(doc.URL); err == nil {
+		base = served
+	}
+	candidates := feedLinks(base, data)
+	if len(candidates) > 0 {
+		return candidates, nil
+	}
+
+	// Fall back to the locations most sites publish their feeds at
+	for _, path := range commonFeedPaths {
+		candidate := base.ResolveReference(&url.URL{Path: path}).String()
+		doc, err := f.fetchDocument(ctx, candidate, CacheValidators{})
+		if err != nil {
+			continue
+		}
+		_, err = f.parseFeed(doc.ContentType, doc.Body)
+		doc.Body.Close()
+		if err == nil {
+			return []string{candidate}, nil
+		}
+	}
+	return nil, fmt.Errorf("no feeds found at %v", pageURL)
+}
+
+// feedLinks extracts the feed URLs advertised in an HTML page's <link> tags.
+//
+// Parameters:
+// - pageURL: The URL the page was fetched from, used to resolve relative links.
+// - data: The raw HTML page.
+//
+// Returns:
+// - A de-duplicated list of absolute feed URLs in document order.
+func feedLinks(pageURL *url.URL, data []byte) []string {
+	var links []string
+	seen := make(map[string]bool)
+	base := pageURL
+
+	tokenizer := html.NewTokenizer(bytes.NewReader(data))
+	for {
+		tokenType := tokenizer.Next()
+		if tokenType == html.ErrorToken {
+			// io.EOF or a malformed document; either way we are done
+			return links
+		}
+		if tokenType != html.StartTagToken && tokenType != html.SelfClosingTagToken {
+			continue
+		}
+		token := tokenizer.Token()
+
+		switch token.DataAtom {
+		case atom.Base:
+			// A <base href> changes how the remaining relative links resolve
+			if href := strings.TrimSpace(attr(token, "href")); href != "" {
+				if resolved, err := pageURL.Parse(href); err == nil {
+					base = resolved
+				}
+			}
+		case atom.Link:
+			if !hasToken(attr(token, "rel"), "alternate") {
+				continue
+			}
+			mediaType := strings.ToLower(strings.TrimSpace(strings.Split(attr(token, "type"), ";")[0]))
+			if !feedMediaTypes[mediaType] {
+				continue
+			}
+			href := strings.TrimSpace(attr(token, "href"))
+			if href == "" {
+				continue
+			}
+			resolved, err := base.Parse(href)
+			if err != nil {
+				continue
+			}
+			if link := resolved.String(); !seen[link] {
+				seen[link] = true
+				links = append(links, link)
+			}
+		case atom.Body:
+			// Feed links live in the document head
+			return links
+		}
+	}
+}
+
+// attr returns the value of an attribute on an HTML token.
+//
+// Parameters:
+// - token: The HTML start tag.
+// - name: The attribute name, in lower case.
+//
+// Returns:
+// - The attribute's value, or an empty string if it is not set.
+func attr(token html.Token, name string) string {
+	for _, a := range token.Attr {
+		if a.Key == name {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+// hasToken reports whether a space-separated attribute value contains a token.
+//
+// Parameters:
+// - value: The attribute value, such as the contents of a rel attribute.
+// - token: The token to look for.
+//
+// Returns:
+// - true if the token is present, compared case-insensitively.
+func hasToken(value, token string) bool {
+	for _, field := range strings.Fields(value) {
+		if strings.EqualFold(field, token) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,114 @@
+package rss
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// maxRetryAfter is the longest Retry-After delay honoured; longer ones are shortened to it.
+const maxRetryAfter = 7 * 24 * time.Hour
+
+// HTTPError is returned when a server answers a fetch with an unsuccessful status.
+type HTTPError struct {
+	StatusCode int           // The HTTP status code, such as 503
+	Status     string        // The HTTP status line, such as "503 Service Unavailable"
+	RetryAfter time.Duration // How long the server asked clients to wait before retrying, or 0
+}
+
+// Error describes the unsuccessful status.
+func (e *HTTPError) Error() string {
+	return fmt.Sprintf("unexpected HTTP status: %v", e.Status)
+}
+
+// StatusCode returns the HTTP status code carried by a fetch error.
+//
+// Parameters:
+// - err: The error returned by a fetch.
+//
+// Returns:
+// - The status code of the server's response, or 0 if the error did not come from one.
+func StatusCode(err error) int {
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr.StatusCode
+	}
+	return 0
+}
+
+// RetryAfter returns how long the server asked clients to wait before fetching again.
+//
+// Parameters:
+// - err: The error returned by a fetch.
+//
+// Returns:
+// - The delay from the response's Retry-After header, or 0 if there is none.
+func RetryAfter(err error) time.Duration {
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr.RetryAfter
+	}
+	return 0
+}
+
+// IsTransient reports whether a fetch error is likely to go away if the fetch is retried:
+// timeouts, dropped connections, server errors and rate limiting.
+//
+// Parameters:
+// - err: The error returned by a fetch.
+//
+// Returns:
+// - true if the fetch is worth retrying.
+func IsTransient(err error) bool {
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) {
+		switch httpErr.StatusCode {
+		case http.StatusRequestTimeout, http.StatusTooManyRequests:
+			return true
+		case http.StatusNotImplemented, http.StatusHTTPVersionNotSupported:
+			return false
+		}
+		return httpErr.StatusCode >= 500
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	return errors.Is(err, context.DeadlineExceeded) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNREFUSED)
+}
+
+// parseRetryAfter parses a Retry-After header, given either in seconds or as an HTTP date.
+//
+// Parameters:
+// - value: The header value.
+// - now: The current time, used for dates.
+//
+// Returns:
+// - The delay the server asked for, at most maxRetryAfter, or 0 if the header is missing or invalid.
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		// Clamp before converting, since a huge number of seconds overflows a Duration
+		return time.Duration(min(seconds, int(maxRetryAfter/time.Second))) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil && date.After(now) {
+		return min(date.Sub(now), maxRetryAfter)
+	}
+	return 0
+}
@@ -0,0 +1,135 @@
+package rss
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+)
+
+const (
+	// defaultTimeout is how long a request may take, including reading the body, unless configured.
+	defaultTimeout = 30 * time.Second
+	// defaultMaxRedirects is the number of redirects followed before a request fails, unless configured.
+	defaultMaxRedirects = 10
+	// defaultUserAgent is the product name sent as the User-Agent, unless configured.
+	defaultUserAgent = "gator"
+	// defaultMaxFeedBytes is the largest document downloaded, in bytes, unless configured.
+	defaultMaxFeedBytes int64 = 10 << 20
+	// defaultMaxFeedItems is the largest number of items read from a feed, unless configured.
+	defaultMaxFeedItems = 1000
+)
+
+// FetcherConfig configures a Fetcher. Fields left at their zero value use the defaults.
+type FetcherConfig struct {
+	Timeout      time.Duration                // How long a request may take, including reading the body
+	ProxyURL     string                       // The proxy to send requests through; empty uses the proxy environment variables
+	CABundle     string                       // The path to a PEM file of extra certificate authorities to trust
+	MaxRedirects int                          // The number of redirects followed before a request fails
+	UserAgent    string                       // The product name sent as the User-Agent header
+	ContactURL   string                       // A URL where publishers can reach the operator, added to the User-Agent
+	Headers      map[string]map[string]string // Extra headers to send, keyed by the exact feed URL (e.g. Authorization for a private feed)
+	MaxBytes     int64                        // The largest document to download, in bytes
+	MaxItems     int                          // The most items to read from a feed; negative reads them all
+}
+
+// Fetcher downloads and parses feeds and web pages with a configured HTTP client and limits.
+type Fetcher struct {
+	client    *http.Client           // The client that sends requests and follows redirects
+	userAgent string                 // The User-Agent header sent with every request
+	headers   map[string]http.Header // Extra headers to send, keyed by feed URL
+	maxBytes  int64                  // The largest document to download, in bytes
+	maxItems  int                    // The most items to read from a feed; zero or less reads them all
+}
+
+// NewFetcher creates a Fetcher from its configuration.
+//
+// Parameters:
+// - config: The client settings and limits; zero values use the defaults.
+//
+// Returns:
+// - A pointer to the Fetcher.
+// - An error if the proxy URL is invalid or the CA bundle cannot be loaded.
+func NewFetcher(config FetcherConfig) (*Fetcher, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	// Send requests through the configured proxy instead of the one from the environment
+	if config.ProxyURL != "" {
+		proxy, err := url.Parse(config.ProxyURL)
+		if err != nil || proxy.Host == "" {
+			return nil, fmt.Errorf("invalid proxy URL %q", config.ProxyURL)
+		}
+		transport.Proxy = http.ProxyURL(proxy)
+	}
+
+	// Trust the extra certificate authorities on top of the system ones
+	if config.CABundle != "" {
+		pem, err := os.ReadFile(config.CABundle)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read CA bundle: %v", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in CA bundle %v", config.CABundle)
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool}
+	}
+
+	timeout := config.Timeout
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	maxRedirects := config.MaxRedirects
+	if maxRedirects <= 0 {
+		maxRedirects = defaultMaxRedirects
+	}
+	client := &http.Client{
+		Transport: &headerTransport{base: &authTransport{base: transport}},
+		Timeout:   timeout,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= maxRedirects {
+				return fmt.Errorf("stopped after %v redirects", maxRedirects)
+			}
+			return nil
+		},
+	}
+
+	// Identify the aggregator, and tell publishers how to reach its operator
+	userAgent := config.UserAgent
+	if userAgent == "" {
+		userAgent = defaultUserAgent
+	}
+	if config.ContactURL != "" {
+		userAgent = fmt.Sprintf("%v (+%v)", userAgent, config.ContactURL)
+	}
+
+	headers := make(map[string]http.Header, len(config.Headers))
+	for feedURL, values := range config.Headers {
+		header := http.Header{}
+		for name, value := range values {
+			header.Set(name, value)
+		}
+		headers[feedURL] = header
+	}
+
+	fetcher := &Fetcher{
+		client:    client,
+		userAgent: userAgent,
+		headers:   headers,
+		maxBytes:  config.MaxBytes,
+		maxItems:  config.MaxItems,
+	}
+	if fetcher.maxBytes <= 0 {
+		fetcher.maxBytes = defaultMaxFeedBytes
+	}
+	if fetcher.maxItems == 0 {
+		fetcher.maxItems = defaultMaxFeedItems
+	}
+	return fetcher, nil
+}
@@ -0,0 +1,39 @@
+package rss
+
+import (
+	"net/http"
+	"strings"
+)
+
+// feedHeaders are the extra headers configured for a feed, along with the origin they belong to.
+type feedHeaders struct {
+	scheme string      // The scheme of the configured feed URL
+	host   string      // The host (and port, if any) of the configured feed URL
+	header http.Header // The headers to send
+}
+
+// feedHeadersKey is the context key the configured headers of a fetch are stored under.
+type feedHeadersKey struct{}
+
+// headerTransport is an http.RoundTripper that adds the configured headers found in a
+// request's context, but only when the request is for the origin they were configured for.
+// The client copies headers set on the request itself to every redirect target, which would
+// leak custom credentials such as X-Api-Key to other hosts.
+type headerTransport struct {
+	base http.RoundTripper // The transport that sends the requests
+}
+
+// RoundTrip sends a request, adding the configured headers if it is for their feed's scheme and host.
+func (t *headerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	headers, ok := req.Context().Value(feedHeadersKey{}).(feedHeaders)
+	if !ok || req.URL.Scheme != headers.scheme || !strings.EqualFold(req.URL.Host, headers.host) {
+		return t.base.RoundTrip(req)
+	}
+
+	// A RoundTripper must not modify the request it was given
+	req = req.Clone(req.Context())
+	for name, values := range headers.header {
+		req.Header[name] = values
+	}
+	return t.base.RoundTrip(req)
+}
@@ -0,0 +1,156 @@
+package rss
+
+import (
+	"strconv"
+	"strings"
+)
+
+// jsonFeedVersionPrefix is the prefix of the version URL every JSON Feed document declares.
+const jsonFeedVersionPrefix = "https://jsonfeed.org/version/"
+
+// jsonFeed represents the structure of a JSON Feed (1.0 or 1.1) document.
+type jsonFeed struct {
+	Version     string           `json:"version"`       // The URL of the JSON Feed version used
+	Title       string           `json:"title"`         // The title of the feed
+	HomePageURL string           `json:"home_page_url"` // The URL of the website the feed describes
+	FeedURL     string           `json:"feed_url"`      // The URL of the feed itself
+	Description string           `json:"description"`   // A brief description of the feed
+	Icon        string           `json:"icon"`          // The URL of a large image for the feed
+	Favicon     string           `json:"favicon"`       // The URL of a small icon for the feed
+	Language    string           `json:"language"`      // The language of the feed (JSON Feed 1.1)
+	Authors     []jsonFeedAuthor `json:"authors"`       // The feed's authors, used for items without their own (JSON Feed 1.1)
+	Author      *jsonFeedAuthor  `json:"author"`        // The feed's author (JSON Feed 1.0)
+	Items       []jsonFeedItem   `json:"items"`         // A list of items (posts) in the feed
+}
+
+// jsonFeedItem represents an individual item (post) in a JSON Feed.
+type jsonFeedItem struct {
+	ID            string               `json:"id"`             // A unique identifier for the item
+	URL           string               `json:"url"`            // The URL of the item's web page
+	ExternalURL   string               `json:"external_url"`   // The URL of a page the item links to
+	Title         string               `json:"title"`          // The title of the item
+	ContentHTML   string               `json:"content_html"`   // The item's content as HTML
+	ContentText   string               `json:"content_text"`   // The item's content as plain text
+	Summary       string               `json:"summary"`        // A short summary of the item
+	DatePublished string               `json:"date_published"` // The publication date in RFC 3339 format
+	DateModified  string               `json:"date_modified"`  // The modification date in RFC 3339 format
+	Authors       []jsonFeedAuthor     `json:"authors"`        // The item's authors (JSON Feed 1.1)
+	Author        *jsonFeedAuthor      `json:"author"`         // The item's author (JSON Feed 1.0)
+	Tags          []string             `json:"tags"`           // Tags describing the item
+	Attachments   []jsonFeedAttachment `json:"attachments"`    // Related resources, such as podcast audio
+}
+
+// jsonFeedAttachment represents a resource attached to a JSON Feed item.
+type jsonFeedAttachment struct {
+	URL               string  `json:"url"`                 // The location of the attachment
+	MimeType          string  `json:"mime_type"`           // The media type of the attachment
+	SizeInBytes       int64   `json:"size_in_bytes"`       // The size of the attachment
+	DurationInSeconds float64 `json:"duration_in_seconds"` // The playing time of the attachment
+}
+
+// jsonFeedAuthor represents the author of a JSON Feed item.
+type jsonFeedAuthor struct {
+	Name string `json:"name"` // The author's name
+	URL  string `json:"url"`  // A URL for the author's site or profile
+}
+
+// authorNames collects the names of JSON Feed authors, accepting both the
+// JSON Feed 1.1 "authors" list and the deprecated 1.0 "author" object.
+//
+// Parameters:
+// - authors: The "authors" list.
+// - author: The "author" object, if present.
+//
+// Returns:
+// - The names of the authors that have one.
+func authorNames(authors []jsonFeedAuthor, author *jsonFeedAuthor) []string {
+	if author != nil {
+		authors = append(authors, *author)
+	}
+	var names []string
+	for _, a := range authors {
+		if a.Name != "" {
+			names = append(names, a.Name)
+		}
+	}
+	return names
+}
+
+// isJSONFeed reports whether a document should be parsed as a JSON Feed.
+//
+// Parameters:
+// - contentType: The Content-Type header sent with the document.
+// - data: The raw document.
+//
+// Returns:
+// - true if the media type is JSON or the body looks like a JSON object.
+func isJSONFeed(contentType string, data []byte) bool {
+	mediaType := strings.ToLower(strings.TrimSpace(strings.Split(contentType, ";")[0]))
+	if mediaType == "application/feed+json" || mediaType == "application/json" {
+		return true
+	}
+	// Servers frequently send JSON Feeds as text/plain, so fall back to sniffing the body.
+	trimmed := strings.TrimLeft(string(data), " \t\r\n\ufeff")
+	return strings.HasPrefix(trimmed, "{")
+}
+
+// toRSS maps a JSON Feed onto the normalized RSSFeed model.
+//
+// Returns:
+// - A pointer to an RSSFeed containing the feed's metadata and items.
+func (j *jsonFeed) toRSS() *RSSFeed {
+	var feed RSSFeed
+	feed.Channel.Title = j.Title
+	feed.Channel.Link = j.HomePageURL
+	feed.Channel.Description = j.Description
+	feed.Channel.Language = j.Language
+	feed.Channel.Image.URL = firstNonEmpty(j.Icon, j.Favicon)
+	feedAuthors := authorNames(j.Authors, j.Author)
+
+	for _, item := range j.Items {
+		// Items without a permalink may still point to an external article.
+		link := item.URL
+		if link == "" {
+			link = item.ExternalURL
+		}
+		// Prefer the HTML body over the plain text one.
+		content := item.ContentHTML
+		if content == "" {
+			content = item.ContentText
+		}
+		// Attachments play the role of RSS enclosures.
+		var enclosures []RSSEnclosure
+		for _, attachment := range item.Attachments {
+			enclosure := RSSEnclosure{URL: attachment.URL, Type: attachment.MimeType}
+			if attachment.SizeInBytes > 0 {
+				enclosure.Length = strconv.FormatInt(attachment.SizeInBytes, 10)
+			}
+			if attachment.DurationInSeconds > 0 {
+				enclosure.Duration = strconv.FormatFloat(attachment.DurationInSeconds, 'f', -1, 64)
+			}
+			enclosures = append(enclosures, enclosure)
+		}
+		// Items without authors inherit those of the feed.
+		authors := authorNames(item.Authors, item.Author)
+		if len(authors) == 0 {
+			authors = feedAuthors
+		}
+		// Use the publication date, or the last modification if it is missing.
+		pubDate := item.DatePublished
+		if pubDate == "" {
+			pubDate = item.DateModified
+		}
+		feed.Channel.Item = append(feed.Channel.Item, RSSItem{
+			Title:       item.Title,
+			Link:        link,
+			Description: item.Summary,
+			PubDate:     pubDate,
+			GUID:        RSSGUID{Value: item.ID, IsPermaLink: "false"},
+			Content:     content,
+			Enclosures:  enclosures,
+			Authors:     authors,
+			Categories:  item.Tags,
+		})
+	}
+	return &feed
+}
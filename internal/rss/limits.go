@@ -0,0 +1,87 @@
+package rss
+
+import (
+	"encoding/xml"
+	"errors"
+	"io"
+)
+
+// ErrFeedTooLarge is returned when a document is larger than a Fetcher's size limit.
+var ErrFeedTooLarge = errors.New("feed is larger than the maximum size")
+
+// limitedBody wraps a response body and fails with ErrFeedTooLarge once more than
+// the allowed number of bytes has been read from it.
+type limitedBody struct {
+	io.ReadCloser       // The response body
+	remaining     int64 // The number of bytes that may still be read
+}
+
+// Read reads from the underlying body, failing once the size limit is exceeded.
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if b.remaining < 0 {
+		return 0, ErrFeedTooLarge
+	}
+	// Read at most one byte past the limit, which is enough to detect an oversized body.
+	if int64(len(p)) > b.remaining+1 {
+		p = p[:b.remaining+1]
+	}
+	n, err := b.ReadCloser.Read(p)
+	b.remaining -= int64(n)
+	if b.remaining < 0 {
+		return n + int(b.remaining), ErrFeedTooLarge
+	}
+	return n, err
+}
+
+// itemLimiter is an xml.TokenReader that passes through the tokens of an XML decoder,
+// dropping every item (or Atom entry) after the first max items so that they are
+// never decoded. Elements nested inside an item are not counted as items.
+type itemLimiter struct {
+	decoder   *xml.Decoder // The decoder reading the document
+	max       int          // The number of items to keep; zero or less keeps all of them
+	count     int          // The number of items seen so far
+	depth     int          // The element depth of the current token
+	itemDepth int          // The depth of the item being read, or 0 outside of items
+	skip      int          // The element depth inside an item being dropped, or 0
+}
+
+// Token returns the next token of the document that is not part of a dropped item.
+func (l *itemLimiter) Token() (xml.Token, error) {
+	for {
+		token, err := l.decoder.Token()
+		if err != nil {
+			return token, err
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			if l.skip > 0 {
+				l.skip++
+				continue
+			}
+			l.depth++
+			if l.itemDepth == 0 && (t.Name.Local == "item" || t.Name.Local == "entry") {
+				l.count++
+				if l.max > 0 && l.count > l.max {
+					l.depth--
+					l.skip = 1
+					continue
+				}
+				l.itemDepth = l.depth
+			}
+		case xml.EndElement:
+			if l.skip > 0 {
+				l.skip--
+				continue
+			}
+			if l.depth == l.itemDepth {
+				l.itemDepth = 0
+			}
+			l.depth--
+		default:
+			if l.skip > 0 {
+				continue
+			}
+		}
+		return token, nil
+	}
+}
@@ -0,0 +1,145 @@
+package rss
+
+import (
+	"bytes"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// urlAttributes lists the HTML attributes holding a URL that are rewritten in item content.
+var urlAttributes = map[string]bool{
+	"href":   true,
+	"src":    true,
+	"poster": true,
+}
+
+// resolveLinks turns the relative URLs in a feed into absolute ones. Each item's links,
+// enclosures and artwork, as well as the href and src attributes in its HTML content,
+// are resolved against its xml:base, then the channel's, then the channel link and
+// finally the URL the feed was fetched from.
+//
+// Parameters:
+// - feedURL: The URL the feed was fetched from.
+func (f *RSSFeed) resolveLinks(feedURL string) {
+	base, err := url.Parse(feedURL)
+	if err != nil {
+		return
+	}
+	base = resolveBase(base, f.XMLBase)
+
+	// The channel's own link is relative to its xml:base. Without an explicit base,
+	// the other links are relative to the site rather than the feed.
+	channelBase := resolveBase(base, f.Channel.XMLBase)
+	f.Channel.Link = resolveURL(channelBase, f.Channel.Link)
+	if strings.TrimSpace(f.Channel.XMLBase) == "" {
+		if site, err := url.Parse(f.Channel.Link); err == nil && site.IsAbs() {
+			channelBase = site
+		}
+	}
+	f.Channel.Image.URL = resolveURL(channelBase, f.Channel.Image.URL)
+	f.Channel.ITunesImage.Href = resolveURL(channelBase, f.Channel.ITunesImage.Href)
+
+	for i := range f.Channel.Item {
+		item := &f.Channel.Item[i]
+		itemBase := resolveBase(channelBase, item.XMLBase)
+		item.Link = resolveURL(itemBase, item.Link)
+		item.ITunesImage.Href = resolveURL(itemBase, item.ITunesImage.Href)
+		for j := range item.Enclosures {
+			item.Enclosures[j].URL = resolveURL(itemBase, item.Enclosures[j].URL)
+		}
+		for j := range item.MediaThumbnails {
+			item.MediaThumbnails[j].URL = resolveURL(itemBase, item.MediaThumbnails[j].URL)
+		}
+		item.Description = resolveHTMLLinks(itemBase, item.Description)
+		item.Content = resolveHTMLLinks(itemBase, item.Content)
+	}
+}
+
+// resolveBase applies an xml:base attribute to the base URL inherited from the parent element.
+//
+// Parameters:
+// - base: The inherited base URL.
+// - xmlBase: The value of the xml:base attribute, which may itself be relative.
+//
+// Returns:
+// - The new base URL, or the inherited one if the attribute is empty or invalid.
+func resolveBase(base *url.URL, xmlBase string) *url.URL {
+	xmlBase = strings.TrimSpace(xmlBase)
+	if xmlBase == "" {
+		return base
+	}
+	resolved, err := base.Parse(xmlBase)
+	if err != nil {
+		return base
+	}
+	return resolved
+}
+
+// resolveURL makes a possibly relative URL absolute.
+//
+// Parameters:
+// - base: The base URL to resolve against.
+// - ref: The URL to resolve.
+//
+// Returns:
+// - The absolute URL, or the trimmed input if it is empty or cannot be parsed.
+func resolveURL(base *url.URL, ref string) string {
+	ref = strings.TrimSpace(ref)
+	if ref == "" || strings.HasPrefix(ref, "#") {
+		return ref
+	}
+	resolved, err := base.Parse(ref)
+	if err != nil {
+		return ref
+	}
+	return resolved.String()
+}
+
+// resolveHTMLLinks rewrites the relative URLs in an HTML fragment's href, src and
+// poster attributes. Tags without relative URLs are copied through unchanged.
+//
+// Parameters:
+// - base: The base URL to resolve against.
+// - fragment: The HTML fragment.
+//
+// Returns:
+// - The fragment with absolute URLs.
+func resolveHTMLLinks(base *url.URL, fragment string) string {
+	if !strings.Contains(fragment, "<") {
+		return fragment
+	}
+	var out strings.Builder
+	tokenizer := html.NewTokenizer(strings.NewReader(fragment))
+	for {
+		tokenType := tokenizer.Next()
+		if tokenType == html.ErrorToken {
+			// io.EOF, or a malformed fragment whose remainder is kept as it was
+			out.Write(tokenizer.Raw())
+			return out.String()
+		}
+		raw := bytes.Clone(tokenizer.Raw())
+		if tokenType != html.StartTagToken && tokenType != html.SelfClosingTagToken {
+			out.Write(raw)
+			continue
+		}
+
+		token := tokenizer.Token()
+		changed := false
+		for i, a := range token.Attr {
+			if a.Namespace != "" || !urlAttributes[a.Key] {
+				continue
+			}
+			if resolved := resolveURL(base, a.Val); resolved != a.Val {
+				token.Attr[i].Val = resolved
+				changed = true
+			}
+		}
+		if changed {
+			out.WriteString(token.String())
+		} else {
+			out.Write(raw)
+		}
+	}
+}
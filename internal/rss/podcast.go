@@ -0,0 +1,155 @@
+package rss
+
+import (
+	"math"
+	"mime"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// mediaTypesByExtension maps common podcast and video file extensions to their media
+// types, as the standard library's table does not cover most of them.
+var mediaTypesByExtension = map[string]string{
+	".mp3":  "audio/mpeg",
+	".m4a":  "audio/mp4",
+	".aac":  "audio/aac",
+	".ogg":  "audio/ogg",
+	".oga":  "audio/ogg",
+	".opus": "audio/opus",
+	".flac": "audio/flac",
+	".wav":  "audio/wav",
+	".mp4":  "video/mp4",
+	".m4v":  "video/x-m4v",
+	".mov":  "video/quicktime",
+	".webm": "video/webm",
+}
+
+// RSSEnclosure represents a media file attached to an RSS item, such as a podcast episode.
+type RSSEnclosure struct {
+	URL      string `xml:"url,attr"`    // The URL of the media file
+	Type     string `xml:"type,attr"`   // The media type of the file, such as "audio/mpeg"
+	Length   string `xml:"length,attr"` // The size of the file in bytes
+	Duration string `xml:"-"`           // The playing time in seconds, when the feed provides one per file
+}
+
+// MediaContent represents a Media RSS <media:content> element.
+type MediaContent struct {
+	URL      string `xml:"url,attr"`      // The URL of the media file
+	Type     string `xml:"type,attr"`     // The media type of the file
+	FileSize string `xml:"fileSize,attr"` // The size of the file in bytes
+	Duration string `xml:"duration,attr"` // The playing time in seconds
+}
+
+// MediaThumbnail represents a Media RSS <media:thumbnail> element.
+type MediaThumbnail struct {
+	URL string `xml:"url,attr"` // The URL of the image
+}
+
+// ITunesImage represents an <itunes:image> element.
+type ITunesImage struct {
+	Href string `xml:"href,attr"` // The URL of the artwork
+}
+
+// Duration returns the playing time declared by the item's <itunes:duration>,
+// which may be given in seconds, MM:SS or HH:MM:SS.
+//
+// Returns:
+// - The playing time.
+// - false if the item declares no valid duration.
+func (item *RSSItem) Duration() (time.Duration, bool) {
+	value := strings.TrimSpace(item.ITunesDuration)
+	if value == "" {
+		return 0, false
+	}
+	var seconds float64
+	for _, part := range strings.Split(value, ":") {
+		n, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil || n < 0 {
+			return 0, false
+		}
+		seconds = seconds*60 + n
+	}
+	if seconds*float64(time.Second) >= math.MaxInt64 {
+		return 0, false // Too long to be a real duration, and would overflow.
+	}
+	return time.Duration(seconds * float64(time.Second)), true
+}
+
+// Episode returns the episode number declared by the item's <itunes:episode>.
+//
+// Returns:
+// - The episode number.
+// - false if the item declares no valid episode number.
+func (item *RSSItem) Episode() (int, bool) {
+	episode, err := strconv.Atoi(strings.TrimSpace(item.ITunesEpisode))
+	if err != nil || episode < 0 {
+		return 0, false
+	}
+	return episode, true
+}
+
+// Artwork returns the URL of the item's episode artwork.
+//
+// Returns:
+// - The <itunes:image> of the item, or its first <media:thumbnail>, or an empty string.
+func (item *RSSItem) Artwork() string {
+	if href := strings.TrimSpace(item.ITunesImage.Href); href != "" {
+		return href
+	}
+	for _, thumbnail := range item.MediaThumbnails {
+		if thumbnailURL := strings.TrimSpace(thumbnail.URL); thumbnailURL != "" {
+			return thumbnailURL
+		}
+	}
+	return ""
+}
+
+// normalizeEnclosures merges the item's <media:content> elements into its enclosures,
+// drops enclosures without a URL and fills in missing media types from file extensions.
+func (item *RSSItem) normalizeEnclosures() {
+	enclosures := item.Enclosures
+	for _, media := range item.MediaContent {
+		enclosures = append(enclosures, RSSEnclosure{
+			URL: media.URL, Type: media.Type, Length: media.FileSize, Duration: media.Duration,
+		})
+	}
+
+	// Keep the first occurrence of each URL, as feeds often list a file in both forms
+	seen := make(map[string]bool)
+	item.Enclosures = nil
+	item.MediaContent = nil
+	for _, enclosure := range enclosures {
+		enclosure.URL = strings.TrimSpace(enclosure.URL)
+		if enclosure.URL == "" || seen[enclosure.URL] {
+			continue
+		}
+		seen[enclosure.URL] = true
+		enclosure.Type = strings.TrimSpace(enclosure.Type)
+		if enclosure.Type == "" {
+			enclosure.Type = guessMediaType(enclosure.URL)
+		}
+		item.Enclosures = append(item.Enclosures, enclosure)
+	}
+}
+
+// guessMediaType derives a media type from the file extension in a URL.
+//
+// Parameters:
+// - fileURL: The URL of the media file.
+//
+// Returns:
+// - The media type, or an empty string if the extension is unknown.
+func guessMediaType(fileURL string) string {
+	parsed, err := url.Parse(fileURL)
+	if err != nil {
+		return ""
+	}
+	ext := strings.ToLower(path.Ext(parsed.Path))
+	if mediaType, ok := mediaTypesByExtension[ext]; ok {
+		return mediaType
+	}
+	return mime.TypeByExtension(ext)
+}
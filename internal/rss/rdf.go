@@ -0,0 +1,88 @@
+package rss
+
+import (
+	"strings"
+)
+
+// rdfNamespace is the XML namespace of the <rdf:RDF> root element used by RSS 1.0.
+const rdfNamespace = "http://www.w3.org/1999/02/22-rdf-syntax-ns#"
+
+// rdfFeed represents the structure of an RSS 1.0 (RDF) feed parsed from XML.
+// Unlike RSS 2.0, items are siblings of the channel rather than its children.
+//
+// Dublin Core fields are declared before their unqualified counterparts because
+// encoding/xml assigns an element to the first field whose local name matches.
+type rdfFeed struct {
+	Channel struct {
+		DCTitle       string `xml:"http://purl.org/dc/elements/1.1/ title"`       // Dublin Core title
+		DCDescription string `xml:"http://purl.org/dc/elements/1.1/ description"` // Dublin Core description
+		DCLanguage    string `xml:"http://purl.org/dc/elements/1.1/ language"`    // Dublin Core language
+		Title         string `xml:"title"`                                        // The title of the feed
+		Link          string `xml:"link"`                                         // The URL of the website the feed describes
+		Description   string `xml:"description"`                                  // A brief description of the feed
+
+		UpdatePeriod    string `xml:"http://purl.org/rss/1.0/modules/syndication/ updatePeriod"`    // The period over which the feed is updated
+		UpdateFrequency string `xml:"http://purl.org/rss/1.0/modules/syndication/ updateFrequency"` // How many times the feed is updated per period
+	} `xml:"channel"`
+	Image RSSImage  `xml:"image"` // The image of the feed, a sibling of the channel
+	Items []rdfItem `xml:"item"`  // A list of items (posts) in the feed
+}
+
+// rdfItem represents an individual item (post) in an RSS 1.0 feed.
+type rdfItem struct {
+	About         string   `xml:"http://www.w3.org/1999/02/22-rdf-syntax-ns# about,attr"` // The item's RDF identifier
+	DCTitle       string   `xml:"http://purl.org/dc/elements/1.1/ title"`                 // Dublin Core title
+	DCDescription string   `xml:"http://purl.org/dc/elements/1.1/ description"`           // Dublin Core description
+	DCDate        string   `xml:"http://purl.org/dc/elements/1.1/ date"`                  // Dublin Core publication date
+	DCCreators    []string `xml:"http://purl.org/dc/elements/1.1/ creator"`               // Dublin Core creators
+	DCSubjects    []string `xml:"http://purl.org/dc/elements/1.1/ subject"`               // Dublin Core subjects
+	Title         string   `xml:"title"`                                                  // The title of the item
+	Link          string   `xml:"link"`                                                   // The URL link to the item
+	Description   string   `xml:"description"`                                            // A brief description of the item
+	Content       string   `xml:"http://purl.org/rss/1.0/modules/content/ encoded"`       // The full HTML content of the item
+}
+
+// firstNonEmpty returns the first of its arguments that is not blank.
+//
+// Parameters:
+// - values: The candidate values, in order of preference.
+//
+// Returns:
+// - The first non-blank value with surrounding whitespace removed, or an empty string.
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if trimmed := strings.TrimSpace(value); trimmed != "" {
+			return trimmed
+		}
+	}
+	return ""
+}
+
+// toRSS maps an RSS 1.0 feed onto the normalized RSSFeed model.
+//
+// Returns:
+// - A pointer to an RSSFeed containing the feed's metadata and items.
+func (r *rdfFeed) toRSS() *RSSFeed {
+	var feed RSSFeed
+	feed.Channel.Title = firstNonEmpty(r.Channel.Title, r.Channel.DCTitle)
+	feed.Channel.Link = strings.TrimSpace(r.Channel.Link)
+	feed.Channel.Description = firstNonEmpty(r.Channel.Description, r.Channel.DCDescription)
+	feed.Channel.Language = strings.TrimSpace(r.Channel.DCLanguage)
+	feed.Channel.Image.URL = strings.TrimSpace(r.Image.URL)
+	feed.Channel.UpdatePeriod = r.Channel.UpdatePeriod
+	feed.Channel.UpdateFrequency = r.Channel.UpdateFrequency
+
+	for _, item := range r.Items {
+		feed.Channel.Item = append(feed.Channel.Item, RSSItem{
+			Title:       firstNonEmpty(item.Title, item.DCTitle),
+			Link:        firstNonEmpty(item.Link, item.About),
+			Description: firstNonEmpty(item.Description, item.DCDescription),
+			PubDate:     strings.TrimSpace(item.DCDate),
+			GUID:        RSSGUID{Value: strings.TrimSpace(item.About), IsPermaLink: "false"},
+			Content:     strings.TrimSpace(item.Content),
+			Authors:     item.DCCreators,
+			Categories:  item.DCSubjects,
+		})
+	}
+	return &feed
+}
@@ -1,33 +1,138 @@
 package rss
 
 import (
+	"bufio"
+	"bytes"
 	"context"
+	"encoding/json"
 	"encoding/xml"
+	"errors"
 	"fmt"
 	"html"
 	"io"
 	"net/http"
+	"strings"
+	"time"
 )
 
+// utf8BOM is the byte order mark some servers prepend to UTF-8 documents.
+var utf8BOM = []byte("\xef\xbb\xbf")
+
+// sniffLen is the number of leading bytes inspected to detect the format of a document.
+const sniffLen = 512
+
 // RSSFeed represents the structure of an RSS feed parsed from XML.
+// Feeds in other formats (such as Atom, RSS 1.0 and JSON Feed) are normalized into this structure.
 type RSSFeed struct {
+	XMLBase string `xml:"http://www.w3.org/XML/1998/namespace base,attr"` // The base URL of the document's relative links
 	Channel struct {
-		Title       string    `xml:"title"`       // The title of the RSS feed
-		Link        string    `xml:"link"`        // The URL link of the RSS feed
-		Description string    `xml:"description"` // A brief description of the RSS feed
-		Item        []RSSItem `xml:"item"`        // A list of items (posts) in the RSS feed
+		XMLBase     string     `xml:"http://www.w3.org/XML/1998/namespace base,attr"` // The base URL of the channel's relative links
+		Title       string     `xml:"title"`                                          // The title of the RSS feed
+		AtomLinks   []atomLink `xml:"http://www.w3.org/2005/Atom link"`               // Atom links (such as rel="self"), declared first so they do not overwrite Link
+		Link        string     `xml:"link"`                                           // The URL link of the RSS feed
+		Description string     `xml:"description"`                                    // A brief description of the RSS feed
+		Item        []RSSItem  `xml:"item"`                                           // A list of items (posts) in the RSS feed
+
+		// Metadata the feed publishes about itself
+		Language    string      `xml:"language"`                                         // The language the feed is written in
+		Generator   string      `xml:"generator"`                                        // The software that generated the feed
+		ITunesImage ITunesImage `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd image"` // The podcast artwork, declared first so it does not overwrite Image
+		Image       RSSImage    `xml:"image"`                                            // The image or logo of the feed
+
+		// Publisher hints about how often the feed should be fetched
+		TTL             string   `xml:"ttl"`                                                          // Minutes the feed may be cached for
+		UpdatePeriod    string   `xml:"http://purl.org/rss/1.0/modules/syndication/ updatePeriod"`    // The period over which the feed is updated
+		UpdateFrequency string   `xml:"http://purl.org/rss/1.0/modules/syndication/ updateFrequency"` // How many times the feed is updated per period
+		SkipHours       []string `xml:"skipHours>hour"`                                               // Hours (GMT) during which the feed should not be fetched
+		SkipDays        []string `xml:"skipDays>day"`                                                 // Days during which the feed should not be fetched
 	} `xml:"channel"`
 }
 
+// RSSImage represents the <image> element of an RSS channel.
+type RSSImage struct {
+	URL string `xml:"url"` // The URL of the image
+}
+
+// ImageURL returns the URL of the image that represents the feed.
+//
+// Returns:
+// - The channel's <image>, or its <itunes:image>, or an empty string.
+func (f *RSSFeed) ImageURL() string {
+	return firstNonEmpty(f.Channel.Image.URL, f.Channel.ITunesImage.Href)
+}
+
 // RSSItem represents an individual item (post) in an RSS feed.
 type RSSItem struct {
-	Title       string `xml:"title"`       // The title of the RSS item
-	Link        string `xml:"link"`        // The URL link to the RSS item
-	Description string `xml:"description"` // A brief description of the RSS item
-	PubDate     string `xml:"pubDate"`     // The publication date of the RSS item
+	// Namespaced elements sharing a name with the plain ones below, declared first so they do not overwrite them
+	AtomLinks        []atomLink `xml:"http://www.w3.org/2005/Atom link"`                 // Atom links (such as rel="self")
+	ITunesTitle      string     `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd title"` // The episode title shown by podcast apps
+	MediaTitle       string     `xml:"http://search.yahoo.com/mrss/ title"`              // The Media RSS title of the item's media
+	MediaDescription string     `xml:"http://search.yahoo.com/mrss/ description"`        // The Media RSS description of the item's media
+
+	Title       string  `xml:"title"`                                            // The title of the RSS item
+	Link        string  `xml:"link"`                                             // The URL link to the RSS item
+	Description string  `xml:"description"`                                      // A brief description of the RSS item
+	PubDate     string  `xml:"pubDate"`                                          // The publication date of the RSS item
+	GUID        RSSGUID `xml:"guid"`                                             // The globally unique identifier of the RSS item
+	Content     string  `xml:"http://purl.org/rss/1.0/modules/content/ encoded"` // The full HTML content of the RSS item
+	XMLBase     string  `xml:"http://www.w3.org/XML/1998/namespace base,attr"`   // The base URL of the RSS item's relative links
+
+	// Authorship and classification
+	DCCreators []string `xml:"http://purl.org/dc/elements/1.1/ creator"` // Dublin Core creators, merged into Authors after parsing
+	Authors    []string `xml:"author"`                                   // The authors of the RSS item
+	Categories []string `xml:"category"`                                 // The categories or tags of the RSS item
+
+	// Podcast and media attachments
+	Enclosures      []RSSEnclosure   `xml:"enclosure"`                                           // Media files attached to the RSS item
+	MediaContent    []MediaContent   `xml:"http://search.yahoo.com/mrss/ content"`               // Media RSS files, merged into Enclosures after parsing
+	MediaThumbnails []MediaThumbnail `xml:"http://search.yahoo.com/mrss/ thumbnail"`             // Media RSS thumbnails of the RSS item
+	ITunesDuration  string           `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd duration"` // The playing time of the episode
+	ITunesEpisode   string           `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd episode"`  // The episode number
+	ITunesImage     ITunesImage      `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd image"`    // The episode artwork
 }
 
-// FetchFeed retrieves and parses an RSS feed from the provided URL.
+// RSSGUID represents the <guid> of an RSS item.
+type RSSGUID struct {
+	Value       string `xml:",chardata"`        // The identifier itself
+	IsPermaLink string `xml:"isPermaLink,attr"` // "false" if the identifier is not a URL; RSS defaults to true
+}
+
+// Identity returns the stable key used to recognize an item across fetches:
+// its GUID, or its link if it has none, or its title as a last resort.
+//
+// Returns:
+// - The item's identity, or an empty string if the item has nothing to identify it by.
+func (item *RSSItem) Identity() string {
+	return firstNonEmpty(item.GUID.Value, item.Link, item.Title)
+}
+
+// CacheValidators holds the HTTP validators used to make conditional requests for a feed.
+type CacheValidators struct {
+	ETag         string // The ETag header returned by the previous fetch
+	LastModified string // The Last-Modified header returned by the previous fetch
+}
+
+// FetchResult holds the outcome of a conditional feed fetch.
+type FetchResult struct {
+	Feed        *RSSFeed        // The parsed feed, or nil if the feed was not modified
+	NotModified bool            // Whether the server answered 304 Not Modified
+	StatusCode  int             // The HTTP status of the response
+	Validators  CacheValidators // The validators to send with the next fetch
+	MovedTo     string          // The URL the feed permanently moved to, or empty if it did not move
+}
+
+// document holds a document downloaded by fetchDocument.
+type document struct {
+	ContentType string          // The Content-Type header sent with the document
+	Body        io.ReadCloser   // The size-limited document body, nil if not modified; the caller must close it
+	NotModified bool            // Whether the server answered 304 Not Modified
+	StatusCode  int             // The HTTP status of the response
+	Validators  CacheValidators // The cache validators sent with the document
+	URL         string          // The URL the document was served from, after any redirects
+	MovedTo     string          // The URL the document permanently moved to, or empty if it did not move
+}
+
+// FetchFeed retrieves and parses an RSS, Atom or JSON feed from the provided URL.
 //
 // Parameters:
 // - ctx: A context for managing request cancellation and timeouts.
@@ -35,34 +140,42 @@ type RSSItem struct {
 //
 // Returns:
 // - A pointer to the RSSFeed struct containing the parsed feed data.
-// - An error if the feed cannot be retrieved or parsed.
-func FetchFeed(ctx context.Context, feedURL string) (*RSSFeed, error) {
-	// Create a new HTTP GET request with the provided context
-	req, err := http.NewRequestWithContext(ctx, "GET", feedURL, nil)
+// - An error if the feed cannot be retrieved or parsed, or ErrHTMLPage if the URL points to a web page.
+func (f *Fetcher) FetchFeed(ctx context.Context, feedURL string) (*RSSFeed, error) {
+	result, err := f.FetchFeedConditional(ctx, feedURL, CacheValidators{})
 	if err != nil {
-		return nil, fmt.Errorf("unable to GET feedURL: %v", err)
+		return nil, err
 	}
+	return result.Feed, nil
+}
 
-	// Add a custom User-Agent header
-	req.Header.Add("user-agent", "gator")
-
-	// Use the default HTTP client to send the request
-	client := http.DefaultClient
-	res, err := client.Do(req)
+// FetchFeedConditional retrieves and parses a feed unless it has not changed since
+// the fetch that returned the given validators.
+// The result records the new URL of a feed that has permanently moved.
+//
+// Parameters:
+// - ctx: A context for managing request cancellation and timeouts.
+// - feedURL: The URL of the feed to fetch.
+// - validators: The validators returned by the previous fetch; empty to fetch unconditionally.
+//
+// Returns:
+// - A pointer to a FetchResult holding the parsed feed, or marked NotModified.
+// - An error if the feed cannot be retrieved or parsed, or ErrHTMLPage if the URL points to a web page.
+func (f *Fetcher) FetchFeedConditional(ctx context.Context, feedURL string, validators CacheValidators) (*FetchResult, error) {
+	// Download the raw feed document
+	doc, err := f.fetchDocument(ctx, feedURL, validators)
 	if err != nil {
-		return nil, fmt.Errorf("unable to get HTTP response: %v", err)
+		return nil, err
 	}
-
-	// Read the response body into memory
-	data, err := io.ReadAll(res.Body)
-	if err != nil {
-		return nil, fmt.Errorf("cannot stream data: %v", err)
+	if doc.NotModified {
+		return &FetchResult{NotModified: true, StatusCode: doc.StatusCode, Validators: doc.Validators, MovedTo: doc.MovedTo}, nil
 	}
+	defer doc.Body.Close()
 
-	// Parse the XML data into an RSSFeed struct
-	var RSSFeed RSSFeed
-	if err := xml.Unmarshal(data, &RSSFeed); err != nil {
-		return nil, fmt.Errorf("error unmarshaling XML: %v", err)
+	// Parse the document into the normalized RSSFeed structure while it streams in
+	RSSFeed, err := f.parseFeed(doc.ContentType, doc.Body)
+	if err != nil {
+		return nil, err
 	}
 
 	// Unescape HTML entities in the RSS feed's title and description
@@ -73,8 +186,235 @@ func FetchFeed(ctx context.Context, feedURL string) (*RSSFeed, error) {
 	for i := range RSSFeed.Channel.Item {
 		RSSFeed.Channel.Item[i].Title = html.UnescapeString(RSSFeed.Channel.Item[i].Title)
 		RSSFeed.Channel.Item[i].Description = html.UnescapeString(RSSFeed.Channel.Item[i].Description)
+
+		// A permalink GUID doubles as the item's link when it has none
+		guid := &RSSFeed.Channel.Item[i].GUID
+		guid.Value = strings.TrimSpace(guid.Value)
+		if RSSFeed.Channel.Item[i].Link == "" && guid.Value != "" && !strings.EqualFold(guid.IsPermaLink, "false") {
+			RSSFeed.Channel.Item[i].Link = guid.Value
+		}
+
+		// Collect every kind of media attachment into the item's enclosures
+		RSSFeed.Channel.Item[i].normalizeEnclosures()
+		RSSFeed.Channel.Item[i].normalizeTaxonomy()
 	}
 
+	// Make relative links absolute so they work outside of the feed
+	RSSFeed.resolveLinks(doc.URL)
+
 	// Return the parsed RSS feed
+	return &FetchResult{Feed: RSSFeed, StatusCode: doc.StatusCode, Validators: doc.Validators, MovedTo: doc.MovedTo}, nil
+}
+
+// fetchDocument retrieves the document at the provided URL, sending
+// If-None-Match and If-Modified-Since headers when validators are given
+// along with any extra headers configured for the URL, which are only sent to its scheme and host.
+// Redirects are followed, and the new URL is recorded when all of them were permanent (301 or 308).
+//
+// Parameters:
+// - ctx: A context for managing request cancellation and timeouts.
+// - docURL: The URL of the document to fetch.
+// - validators: The validators returned by the previous fetch, if any.
+//
+// Returns:
+// - A pointer to the document, whose body the caller must close, marked NotModified on a 304 response.
+// - An error if the request fails, the status is not a success or the body is announced as too large.
+func (f *Fetcher) fetchDocument(ctx context.Context, docURL string, validators CacheValidators) (*document, error) {
+	// Create a new HTTP GET request with the provided context
+	req, err := http.NewRequestWithContext(ctx, "GET", docURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("unable to GET feedURL: %v", err)
+	}
+
+	// Identify the aggregator, and leave the headers configured for this feed to the transport
+	// so they are only sent to the feed's own scheme and host, not to redirect targets
+	req.Header.Set("User-Agent", f.userAgent)
+	if header, ok := f.headers[docURL]; ok {
+		req = req.WithContext(context.WithValue(ctx, feedHeadersKey{}, feedHeaders{
+			scheme: req.URL.Scheme,
+			host:   req.URL.Host,
+			header: header,
+		}))
+	}
+
+	// Ask the server to skip the body if nothing changed since the previous fetch
+	if validators.ETag != "" {
+		req.Header.Set("If-None-Match", validators.ETag)
+	}
+	if validators.LastModified != "" {
+		req.Header.Set("If-Modified-Since", validators.LastModified)
+	}
+
+	res, err := f.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get HTTP response: %w", err)
+	}
+
+	// Walk back through the redirects that were followed, noting whether every one was permanent
+	redirected, permanent := false, true
+	for r := res.Request; r.Response != nil; r = r.Response.Request {
+		redirected = true
+		if code := r.Response.StatusCode; code != http.StatusMovedPermanently && code != http.StatusPermanentRedirect {
+			permanent = false
+		}
+	}
+	finalURL := res.Request.URL.String()
+	movedTo := ""
+	if redirected && permanent && finalURL != docURL {
+		movedTo = finalURL
+	}
+
+	// Keep the previous validators unless the server sent new ones
+	next := validators
+	if etag := res.Header.Get("ETag"); etag != "" {
+		next.ETag = etag
+	}
+	if lastModified := res.Header.Get("Last-Modified"); lastModified != "" {
+		next.LastModified = lastModified
+	}
+	if res.StatusCode == http.StatusNotModified {
+		res.Body.Close()
+		return &document{NotModified: true, StatusCode: res.StatusCode, Validators: next, URL: finalURL, MovedTo: movedTo}, nil
+	}
+
+	// Reject error pages so they are not mistaken for documents
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		res.Body.Close()
+		return nil, &HTTPError{
+			StatusCode: res.StatusCode,
+			Status:     res.Status,
+			RetryAfter: parseRetryAfter(res.Header.Get("Retry-After"), time.Now()),
+		}
+	}
+
+	// Refuse oversized bodies up front when the server announces their size,
+	// and stop reading the others once they grow past the limit
+	if res.ContentLength > f.maxBytes {
+		res.Body.Close()
+		return nil, ErrFeedTooLarge
+	}
+	body := &limitedBody{ReadCloser: res.Body, remaining: f.maxBytes}
+	return &document{
+		ContentType: res.Header.Get("Content-Type"),
+		Body:        body,
+		StatusCode:  res.StatusCode,
+		Validators:  next,
+		URL:         finalURL,
+		MovedTo:     movedTo,
+	}, nil
+}
+
+// parseFeed detects the format of a feed document and decodes it into an RSSFeed
+// as it is read, without holding the raw document in memory.
+//
+// Parameters:
+// - contentType: The Content-Type header sent with the document.
+// - body: The raw feed document.
+//
+// Returns:
+// - A pointer to the RSSFeed struct containing the normalized feed data.
+// - An error if the document cannot be parsed, ErrHTMLPage if it is a web page or ErrFeedTooLarge.
+func (f *Fetcher) parseFeed(contentType string, body io.Reader) (*RSSFeed, error) {
+	// Look at the start of the document to tell its format
+	reader := bufio.NewReader(body)
+	head, err := reader.Peek(sniffLen)
+	if err != nil && err != io.EOF {
+		return nil, decodeError("cannot read feed", err)
+	}
+
+	// Web pages are reported separately so callers can look for the feeds they link to
+	if isHTML(head) {
+		return nil, ErrHTMLPage
+	}
+
+	// JSON Feeds are mapped onto the RSS model after decoding
+	if isJSONFeed(contentType, head) {
+		if bytes.HasPrefix(head, utf8BOM) {
+			reader.Discard(len(utf8BOM))
+		}
+		var jf jsonFeed
+		if err := json.NewDecoder(reader).Decode(&jf); err != nil {
+			return nil, decodeError("error unmarshaling JSON Feed", err)
+		}
+		if !strings.HasPrefix(jf.Version, jsonFeedVersionPrefix) {
+			return nil, fmt.Errorf("unsupported JSON Feed version: %q", jf.Version)
+		}
+		if f.maxItems > 0 && len(jf.Items) > f.maxItems {
+			jf.Items = jf.Items[:f.maxItems]
+		}
+		return jf.toRSS(), nil
+	}
+
+	// Items past the limit are dropped from the token stream before they are decoded
+	decoder := xml.NewTokenDecoder(&itemLimiter{decoder: newXMLDecoder(contentType, reader), max: f.maxItems})
+	root, err := rootElement(decoder)
+	if err != nil {
+		return nil, decodeError("error unmarshaling XML", err)
+	}
+
+	// XHTML pages are well-formed XML, so check the root element as well
+	if root.Name.Local == "html" {
+		return nil, ErrHTMLPage
+	}
+
+	// Atom feeds are mapped onto the RSS model after parsing
+	if root.Name.Space == atomNamespace && root.Name.Local == "feed" {
+		var atom atomFeed
+		if err := decoder.DecodeElement(&atom, &root); err != nil {
+			return nil, decodeError("error unmarshaling Atom XML", err)
+		}
+		return atom.toRSS(), nil
+	}
+
+	// RSS 1.0 feeds keep their items outside of the channel
+	if root.Name.Space == rdfNamespace && root.Name.Local == "RDF" {
+		var rdf rdfFeed
+		if err := decoder.DecodeElement(&rdf, &root); err != nil {
+			return nil, decodeError("error unmarshaling RDF XML", err)
+		}
+		return rdf.toRSS(), nil
+	}
+
+	// Everything else is treated as RSS 2.0
+	var RSSFeed RSSFeed
+	if err := decoder.DecodeElement(&RSSFeed, &root); err != nil {
+		return nil, decodeError("error unmarshaling XML", err)
+	}
 	return &RSSFeed, nil
 }
+
+// rootElement reads an XML document up to its first element.
+//
+// Parameters:
+// - decoder: The decoder reading the document.
+//
+// Returns:
+// - The start tag of the root element, which the caller decodes the document from.
+// - An error if the document contains no element.
+func rootElement(decoder *xml.Decoder) (xml.StartElement, error) {
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return xml.StartElement{}, err
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			return start, nil
+		}
+	}
+}
+
+// decodeError describes a failure to decode a feed, passing ErrFeedTooLarge through
+// unchanged so that callers can tell oversized feeds from malformed ones.
+//
+// Parameters:
+// - message: A description of what failed.
+// - err: The underlying error.
+//
+// Returns:
+// - ErrFeedTooLarge, or the message combined with the error.
+func decodeError(message string, err error) error {
+	if errors.Is(err, ErrFeedTooLarge) {
+		return ErrFeedTooLarge
+	}
+	return fmt.Errorf("%v: %w", message, err)
+}
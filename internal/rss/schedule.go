@@ -0,0 +1,77 @@
+package rss
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// updatePeriods maps the values of <sy:updatePeriod> to their duration.
+var updatePeriods = map[string]time.Duration{
+	"hourly":  time.Hour,
+	"daily":   24 * time.Hour,
+	"weekly":  7 * 24 * time.Hour,
+	"monthly": 30 * 24 * time.Hour,
+	"yearly":  365 * 24 * time.Hour,
+}
+
+// MinRefreshInterval returns the shortest interval the publisher asks readers to wait
+// between fetches, based on the feed's <ttl> and syndication module elements.
+//
+// Returns:
+// - The longest of the advertised intervals, or zero if the feed advertises none.
+func (f *RSSFeed) MinRefreshInterval() time.Duration {
+	var interval time.Duration
+
+	// <ttl> is expressed in minutes; anything above a year is treated as a year so it cannot overflow
+	if ttl, err := strconv.Atoi(strings.TrimSpace(f.Channel.TTL)); err == nil && ttl > 0 {
+		interval = time.Duration(min(ttl, 365*24*60)) * time.Minute
+	}
+
+	// <sy:updatePeriod> is divided by <sy:updateFrequency>, which defaults to 1
+	if period, ok := updatePeriods[strings.ToLower(strings.TrimSpace(f.Channel.UpdatePeriod))]; ok {
+		frequency, err := strconv.Atoi(strings.TrimSpace(f.Channel.UpdateFrequency))
+		if err != nil || frequency < 1 {
+			frequency = 1
+		}
+		if syInterval := period / time.Duration(frequency); syInterval > interval {
+			interval = syInterval
+		}
+	}
+	return interval
+}
+
+// NextAllowedFetch moves a fetch time forward until it falls outside the feed's
+// <skipHours> and <skipDays>, which are interpreted in GMT.
+//
+// Parameters:
+// - t: The earliest time the feed could be fetched.
+//
+// Returns:
+// - The earliest time at or after t that the publisher allows fetching.
+func (f *RSSFeed) NextAllowedFetch(t time.Time) time.Time {
+	skipHours := make(map[int]bool)
+	for _, value := range f.Channel.SkipHours {
+		if hour, err := strconv.Atoi(strings.TrimSpace(value)); err == nil {
+			// Some publishers write midnight as 24
+			skipHours[hour%24] = true
+		}
+	}
+	skipDays := make(map[string]bool)
+	for _, value := range f.Channel.SkipDays {
+		skipDays[strings.ToLower(strings.TrimSpace(value))] = true
+	}
+	if len(skipHours) == 0 && len(skipDays) == 0 {
+		return t
+	}
+
+	// Step through whole hours for at most a week, in case every slot is skipped
+	next := t.UTC()
+	for i := 0; i < 7*24; i++ {
+		if !skipHours[next.Hour()] && !skipDays[strings.ToLower(next.Weekday().String())] {
+			return next
+		}
+		next = next.Truncate(time.Hour).Add(time.Hour)
+	}
+	return t
+}
@@ -30,9 +30,17 @@ func main() {
 	// Load application configuration from the environment or config file
 	conf := config.Read()
 
+	// Build the feed fetcher from the configured client settings and limits
+	fetcher, err := conf.NewFetcher()
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
 	// Initialize application state
 	state := config.State{
-		ConfigPtr: &conf, // Link configuration to state
+		ConfigPtr: &conf,   // Link configuration to state
+		Fetcher:   fetcher, // Share one HTTP client across all fetches
 	}
 
 	// Initialize a command registry
@@ -53,11 +61,17 @@ func main() {
 	commands.Register("users", config.HandlerGetUsers)
 	commands.Register("agg", config.HandlerAgg)
 	commands.Register("feeds", config.HandlerFeeds)
+	commands.Register("feed-status", config.HandlerFeedStatus)
 	commands.Register("addfeed", config.MiddlewareLoggedIn(config.HandlerAddFeed))
 	commands.Register("follow", config.MiddlewareLoggedIn(config.HandlerFollow))
 	commands.Register("following", config.MiddlewareLoggedIn(config.HandlerFollowing))
 	commands.Register("unfollow", config.MiddlewareLoggedIn(config.HandlerUnfollow))
+	commands.Register("enable-feed", config.MiddlewareLoggedIn(config.HandlerEnableFeed))
+	commands.Register("disable-feed", config.MiddlewareLoggedIn(config.HandlerDisableFeed))
 	commands.Register("browse", config.MiddlewareLoggedIn(config.HandlerBrowse))
+	commands.Register("episodes", config.MiddlewareLoggedIn(config.HandlerEpisodes))
+	commands.Register("import-opml", config.MiddlewareLoggedIn(config.HandlerImportOPML))
+	commands.Register("export-opml", config.MiddlewareLoggedIn(config.HandlerExportOPML))
 
 	// Establish a database connection using the provided configuration
 	db, err := sql.Open("postgres", state.ConfigPtr.DbUrl)
@@ -69,6 +83,7 @@ func main() {
 	// Initialize database queries for application use
 	dbQueries := database.New(db)
 	state.Db = dbQueries
+	state.Conn = db
 
 	// Execute the requested command
 	if err := commands.Run(&state, command); err != nil {
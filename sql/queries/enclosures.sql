@@ -0,0 +1,77 @@
+-- name: UpsertEnclosure :exec
+-- Insert or update a media file attached to the post with the given GUID in a feed
+INSERT INTO enclosures (
+        id,
+        -- Unique identifier for the enclosure
+        post_id,
+        -- Post the file is attached to
+        url,
+        -- URL of the media file
+        mime_type,
+        -- Media type of the file
+        length,
+        -- Size of the file in bytes
+        duration,
+        -- Playing time in seconds
+        episode,
+        -- Episode number
+        image_url -- URL of the episode artwork
+    )
+SELECT sqlc.arg(id),
+    posts.id,
+    sqlc.arg(url),
+    sqlc.arg(mime_type),
+    sqlc.arg(length),
+    sqlc.arg(duration),
+    sqlc.arg(episode),
+    sqlc.arg(image_url)
+FROM posts
+WHERE posts.feed_id = sqlc.arg(feed_id) -- Find the post by its feed
+    AND posts.guid = sqlc.arg(guid) -- and its GUID within that feed
+    ON CONFLICT (post_id, url) DO
+UPDATE
+SET mime_type = EXCLUDED.mime_type,
+    -- Replace the media type with the latest version
+    length = EXCLUDED.length,
+    -- Replace the size with the latest version
+    duration = EXCLUDED.duration,
+    -- Replace the playing time with the latest version
+    episode = EXCLUDED.episode,
+    -- Replace the episode number with the latest version
+    image_url = EXCLUDED.image_url,
+    -- Replace the artwork with the latest version
+    updated_at = CURRENT_TIMESTAMP;
+-- Update the modified timestamp to now
+-- name: GetEpisodesForUser :many
+-- Retrieve the most recent audio and video files from feeds followed by a specific user
+SELECT posts.title,
+    -- Title of the post
+    posts.published_at,
+    -- Publication timestamp of the post
+    posts.published_at_inferred,
+    -- Whether the publication timestamp is the first-seen time
+    feeds.name AS feed_name,
+    -- Name of the feed the post belongs to
+    enclosures.url,
+    -- Download URL of the media file
+    enclosures.mime_type,
+    -- Media type of the file
+    enclosures.length,
+    -- Size of the file in bytes
+    enclosures.duration,
+    -- Playing time in seconds
+    enclosures.episode,
+    -- Episode number
+    enclosures.image_url -- URL of the episode artwork
+FROM enclosures
+    INNER JOIN posts ON posts.id = enclosures.post_id
+    INNER JOIN feeds ON feeds.id = posts.feed_id
+    INNER JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
+WHERE feed_follows.user_id = $1 -- Filter by the user ID
+    AND (
+        enclosures.mime_type LIKE 'audio/%'
+        OR enclosures.mime_type LIKE 'video/%'
+    ) -- Only playable media
+ORDER BY posts.published_at DESC NULLS LAST -- Most recent first
+LIMIT $2;
+-- Limit the number of episodes returned
@@ -0,0 +1,19 @@
+-- name: UpsertFeedCredentials :exec
+-- Store the encrypted credentials of a feed, replacing any it already has
+INSERT INTO feed_credentials (id, feed_id, host, secret)
+VALUES ($1, $2, $3, $4) ON CONFLICT (feed_id) DO
+UPDATE
+SET host = EXCLUDED.host,
+    -- Replace the host the credentials belong to
+    secret = EXCLUDED.secret,
+    -- Replace the encrypted credentials
+    updated_at = CURRENT_TIMESTAMP;
+-- Update the modified timestamp to now
+-- name: GetFeedCredentials :one
+-- Retrieve the encrypted credentials of a feed
+SELECT host,
+    -- Host the credentials may be sent to
+    secret -- Credentials encrypted with the configured key
+FROM feed_credentials
+WHERE feed_id = $1;
+-- Filter by the feed ID
@@ -30,4 +30,24 @@ WHERE users.id = $1;
 DELETE FROM feed_follows
 WHERE user_id = $1 -- Specify the user ID
     AND feed_id = $2;
--- Specify the feed ID
\ No newline at end of file
+-- Specify the feed ID
+-- name: MoveFeedFollows :exec
+-- Move the follows of one feed to another, skipping users who already follow both
+UPDATE feed_follows
+SET feed_id = sqlc.arg(to_feed_id),
+    -- Feed the follows move to
+    updated_at = CURRENT_TIMESTAMP -- Update the modified timestamp to now
+WHERE feed_follows.feed_id = sqlc.arg(from_feed_id) -- Feed the follows move away from
+    AND feed_follows.user_id NOT IN (
+        SELECT existing.user_id
+        FROM feed_follows AS existing
+        WHERE existing.feed_id = sqlc.arg(to_feed_id)
+    );
+-- name: IsFollowing :one
+-- Check whether a user follows a feed
+SELECT EXISTS (
+        SELECT 1
+        FROM feed_follows
+        WHERE user_id = $1 -- Specify the user ID
+            AND feed_id = $2 -- Specify the feed ID
+    );
@@ -0,0 +1,22 @@
+-- name: AddFeedURLHistory :exec
+-- Record a URL a feed has moved away from, taking it over from any other feed that used it
+INSERT INTO feed_url_history (id, feed_id, url)
+VALUES ($1, $2, $3) ON CONFLICT (url) DO
+UPDATE
+SET feed_id = EXCLUDED.feed_id,
+    -- Point the old URL at the feed that now lives elsewhere
+    updated_at = CURRENT_TIMESTAMP;
+-- Update the modified timestamp to now
+-- name: DeleteFeedURLHistory :exec
+-- Forget a previous URL, once a feed lives at it again
+DELETE FROM feed_url_history
+WHERE url = $1;
+-- Specify the URL
+-- name: MoveFeedURLHistory :exec
+-- Hand the previous URLs of a feed over to the feed it is merged into
+UPDATE feed_url_history
+SET feed_id = sqlc.arg(to_feed_id),
+    -- Feed the URLs now lead to
+    updated_at = CURRENT_TIMESTAMP -- Update the modified timestamp to now
+WHERE feed_id = sqlc.arg(from_feed_id);
+-- Feed being merged away
@@ -10,28 +10,213 @@ SELECT feeds.name,
     -- Name of the feed
     feeds.url,
     -- URL of the feed
+    feeds.site_title,
+    -- Title the feed gives itself
+    feeds.site_url,
+    -- URL of the website the feed belongs to
+    feeds.site_description,
+    -- Description the feed gives itself
+    feeds.language,
+    -- Language the feed is written in
+    feeds.image_url,
+    -- URL of the feed's image
+    feeds.generator,
+    -- Software that generated the feed
     users.name -- Name of the user who added the feed
 FROM feeds
     INNER JOIN users ON feeds.user_id = users.id;
 -- name: GetFeed :one
--- Retrieve a feed by its URL
+-- Retrieve a feed by its URL, or by a URL it was at before it moved
 SELECT id,
     -- Unique identifier for the feed
     name -- Name of the feed
 FROM feeds
-WHERE url = $1;
--- name: MarkFeedFetched :exec
--- Update the `last_fetched_at` and `updated_at` timestamps for a feed
+WHERE feeds.url = $1 -- The feed's current URL
+    OR feeds.id IN (
+        SELECT feed_url_history.feed_id
+        FROM feed_url_history
+        WHERE feed_url_history.url = $1 -- A URL the feed moved away from
+    )
+ORDER BY feeds.url = $1 DESC -- Prefer a feed currently at the URL
+LIMIT 1;
+-- name: GetFeedIDByURL :one
+-- Retrieve the ID of the feed currently at a URL, locking it until the transaction ends
+SELECT id
+FROM feeds
+WHERE url = $1 FOR
+UPDATE;
+-- name: UpdateFeedURL :exec
+-- Move a feed to a new URL
+UPDATE feeds
+SET url = $2,
+    -- New URL of the feed
+    updated_at = CURRENT_TIMESTAMP -- Update the modified timestamp to now
+WHERE id = $1;
+-- name: DeleteFeed :exec
+-- Delete a feed, together with its remaining follows and posts
+DELETE FROM feeds
+WHERE id = $1;
+-- name: UpdateFeedCacheValidators :exec
+-- Store the HTTP cache validators returned by the latest fetch of a feed
+UPDATE feeds
+SET etag = $2,
+    -- ETag header to send as If-None-Match
+    last_modified = $3,
+    -- Last-Modified header to send as If-Modified-Since
+    updated_at = CURRENT_TIMESTAMP -- Update the modified timestamp to now
+WHERE id = $1;
+-- name: UpdateFeedMetadata :exec
+-- Store the metadata a feed published about itself in its latest version
+UPDATE feeds
+SET site_title = $2,
+    -- Title the feed gives itself
+    site_url = $3,
+    -- URL of the website the feed belongs to
+    site_description = $4,
+    -- Description the feed gives itself
+    language = $5,
+    -- Language the feed is written in
+    image_url = $6,
+    -- URL of the feed's image, logo or icon
+    generator = $7,
+    -- Software that generated the feed
+    updated_at = CURRENT_TIMESTAMP -- Update the modified timestamp to now
+WHERE id = $1;
+-- name: GetNextFeedsToFetch :many
+-- Claim a batch of feeds that are due to be fetched by marking them as fetched
+-- Claimed feeds are provisionally rescheduled one interval ahead until ScheduleFeed runs
+-- Rows locked by another aggregator are skipped, so a feed is never claimed twice
 UPDATE feeds
 SET last_fetched_at = CURRENT_TIMESTAMP,
     -- Set the last fetched timestamp to now
+    next_fetch_at = CURRENT_TIMESTAMP + fetch_interval * INTERVAL '1 second',
+    -- Hold the feed back for one interval
     updated_at = CURRENT_TIMESTAMP -- Update the modified timestamp to now
-WHERE id = $1;
--- name: GetNextFeedToFetch :one
--- Retrieve the next feed to fetch, prioritizing the least recently fetched
-SELECT id,
+WHERE id IN (
+        SELECT id
+        FROM feeds
+        WHERE status <> 'disabled' -- Disabled feeds are never fetched
+            AND (
+                next_fetch_at IS NULL -- Never scheduled, so due immediately
+                OR next_fetch_at <= CURRENT_TIMESTAMP -- Scheduled time has passed
+            )
+        ORDER BY next_fetch_at ASC NULLS FIRST,
+            -- Most overdue first (unscheduled feeds come first)
+            last_fetched_at ASC NULLS FIRST -- Then least recently fetched
+        LIMIT $1 -- Claim at most this many feeds
+        FOR UPDATE SKIP LOCKED -- Skip feeds being claimed concurrently
+    )
+RETURNING id,
     -- Unique identifier for the feed
-    url -- URL of the feed
+    url,
+    -- URL of the feed
+    etag,
+    -- ETag header from the last fetch, if any
+    last_modified,
+    -- Last-Modified header from the last fetch, if any
+    fetch_interval,
+    -- Current interval between fetches, in seconds
+    consecutive_failures,
+    -- Number of failed fetches since the last successful one
+    has_legacy_posts;
+-- Whether some posts may still be keyed by their URL
+-- name: ScheduleFeed :exec
+-- Store a feed's adapted fetch interval and when it is next due
+UPDATE feeds
+SET fetch_interval = sqlc.arg(fetch_interval),
+    -- New interval between fetches, in seconds
+    next_fetch_at = CURRENT_TIMESTAMP + sqlc.arg(delay)::INTEGER * INTERVAL '1 second',
+    -- Due again after the given delay, in seconds
+    updated_at = CURRENT_TIMESTAMP -- Update the modified timestamp to now
+WHERE id = sqlc.arg(id);
+-- name: SetFeedLegacyPosts :exec
+-- Record whether a feed may still have posts keyed by their URL
+UPDATE feeds
+SET has_legacy_posts = $2,
+    -- False once every post in the feed has been rekeyed to its GUID
+    updated_at = CURRENT_TIMESTAMP -- Update the modified timestamp to now
+WHERE id = $1;
+-- name: RecordFeedSuccess :exec
+-- Record a successful fetch, clearing the feed's failure streak
+UPDATE feeds
+SET last_status = $2,
+    -- HTTP status of the response
+    consecutive_failures = 0,
+    -- Reset the failure streak
+    status = CASE
+        WHEN status = 'degraded' THEN 'active'
+        ELSE status
+    END,
+    -- A degraded feed that responds is healthy again; a feed disabled meanwhile stays disabled
+    updated_at = CURRENT_TIMESTAMP -- Update the modified timestamp to now
+WHERE id = $1;
+-- name: RecordFeedFailure :exec
+-- Record a failed fetch and hold the feed back until it is retried
+-- The status moves the feed to degraded or disabled once it has failed often enough
+UPDATE feeds
+SET last_status = sqlc.narg(last_status),
+    -- HTTP status of the response, if one was received
+    last_error = sqlc.arg(last_error),
+    -- Description of the failure
+    last_error_at = CURRENT_TIMESTAMP,
+    -- Set the failure timestamp to now
+    consecutive_failures = consecutive_failures + 1,
+    -- Extend the failure streak
+    status = sqlc.arg(status),
+    -- Lifecycle status after this failure
+    disabled_reason = sqlc.narg(disabled_reason),
+    -- Why the feed was disabled, if it now is
+    next_fetch_at = CURRENT_TIMESTAMP + sqlc.arg(delay)::INTEGER * INTERVAL '1 second',
+    -- Due again after the backoff delay, in seconds
+    updated_at = CURRENT_TIMESTAMP -- Update the modified timestamp to now
+WHERE id = sqlc.arg(id);
+-- name: GetFeedStatuses :many
+-- Retrieve the fetch status of every feed, disabled and failing feeds first
+SELECT name,
+    -- Name of the feed
+    url,
+    -- URL of the feed
+    status,
+    -- Lifecycle status of the feed
+    disabled_reason,
+    -- Why the feed was disabled, if it is
+    last_fetched_at,
+    -- When the feed was last fetched
+    next_fetch_at,
+    -- When the feed is next due to be fetched
+    fetch_interval,
+    -- Current interval between fetches, in seconds
+    last_status,
+    -- HTTP status of the latest response
+    consecutive_failures,
+    -- Number of failed fetches since the last successful one
+    last_error,
+    -- Error of the latest failed fetch
+    last_error_at -- When the latest failed fetch happened
 FROM feeds
-ORDER BY last_fetched_at ASC NULLS FIRST -- Order by least recently fetched (NULLs come first)
-LIMIT 1;
\ No newline at end of file
+ORDER BY status DESC,
+    -- Disabled, then degraded, then active feeds
+    consecutive_failures DESC,
+    name ASC;
+-- name: DisableFeed :exec
+-- Stop fetching a feed until it is enabled again
+UPDATE feeds
+SET status = 'disabled',
+    -- Exclude the feed from scheduling
+    disabled_reason = $2,
+    -- Why the feed was disabled
+    updated_at = CURRENT_TIMESTAMP -- Update the modified timestamp to now
+WHERE id = $1;
+-- name: EnableFeed :exec
+-- Resume fetching a feed, giving it a clean slate and making it due immediately
+UPDATE feeds
+SET status = 'active',
+    -- Include the feed in scheduling again
+    disabled_reason = NULL,
+    -- Forget why it was disabled
+    consecutive_failures = 0,
+    -- Reset the failure streak
+    next_fetch_at = NULL,
+    -- Due immediately
+    updated_at = CURRENT_TIMESTAMP -- Update the modified timestamp to now
+WHERE id = $1;
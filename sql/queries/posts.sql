@@ -1,5 +1,24 @@
--- name: CreatePost :exec
--- Insert a new post into the `posts` table
+-- name: RekeyLegacyPost :exec
+-- Give a post stored before posts had GUIDs the GUID of its feed item
+-- Such posts were keyed by their URL, which migration 008 copied into their GUID
+UPDATE posts
+SET guid = sqlc.arg(guid),
+    -- GUID of the feed item the post came from
+    updated_at = CURRENT_TIMESTAMP -- Update the modified timestamp to now
+WHERE posts.feed_id = sqlc.arg(feed_id) -- Feed the post belongs to
+    AND posts.guid = posts.url -- Still keyed by its URL
+    AND posts.url = sqlc.arg(url) -- Link of the feed item
+    AND NOT EXISTS (
+        SELECT 1
+        FROM posts AS existing
+        WHERE existing.feed_id = sqlc.arg(feed_id)
+            AND existing.guid = sqlc.arg(guid)
+    );
+-- Unless the item was already stored under its GUID
+-- name: UpsertPost :one
+-- Insert a new post, or update the stored post with the same GUID in the feed if it changed
+-- Returns whether the post was inserted; no row is returned when the post is unchanged
+-- An inferred publication date never replaces the stored one
 INSERT INTO posts (
         id,
         -- Unique identifier for the post
@@ -11,21 +30,105 @@ INSERT INTO posts (
         -- Brief description of the post
         published_at,
         -- Publication timestamp of the post
-        feed_id -- Foreign key linking to the `feeds` table
+        feed_id,
+        -- Foreign key linking to the `feeds` table
+        guid,
+        -- GUID of the item within its feed
+        content,
+        -- Full content of the post
+        authors,
+        -- Names of the post's authors
+        categories,
+        -- Categories the post is filed under
+        published_at_inferred -- Whether the publication date is the first-seen time
     )
-VALUES ($1, $2, $3, $4, $5, $6);
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11) ON CONFLICT (feed_id, guid) DO
+UPDATE
+SET title = EXCLUDED.title,
+    -- Replace the title with the latest version
+    url = EXCLUDED.url,
+    -- Replace the URL, which may have gained tracking parameters
+    description = EXCLUDED.description,
+    -- Replace the description with the latest version
+    published_at = CASE
+        WHEN EXCLUDED.published_at_inferred THEN posts.published_at
+        ELSE EXCLUDED.published_at
+    END,
+    -- Replace the publication timestamp unless the new one is only inferred
+    published_at_inferred = posts.published_at_inferred
+    AND EXCLUDED.published_at_inferred,
+    -- The date stays inferred until the feed provides a real one
+    content = EXCLUDED.content,
+    -- Replace the full content with the latest version
+    authors = EXCLUDED.authors,
+    -- Replace the authors with the latest version
+    categories = EXCLUDED.categories,
+    -- Replace the categories with the latest version
+    updated_at = CURRENT_TIMESTAMP -- Update the modified timestamp to now
+WHERE posts.title IS DISTINCT FROM EXCLUDED.title
+    OR posts.url IS DISTINCT FROM EXCLUDED.url
+    OR posts.description IS DISTINCT FROM EXCLUDED.description
+    OR (
+        NOT EXCLUDED.published_at_inferred
+        AND (
+            posts.published_at IS DISTINCT FROM EXCLUDED.published_at
+            OR posts.published_at_inferred
+        )
+    )
+    OR posts.content IS DISTINCT FROM EXCLUDED.content
+    OR posts.authors IS DISTINCT FROM EXCLUDED.authors
+    OR posts.categories IS DISTINCT FROM EXCLUDED.categories
+RETURNING (xmax = 0) AS inserted;
+-- xmax is only zero for freshly inserted rows
 -- name: GetPostsForUser :many
 -- Retrieve posts for all feeds followed by a specific user
+-- Optionally narrow the posts down to an author and/or a category (matched case-insensitively)
 SELECT posts.title,
     -- Title of the post
     posts.url,
     -- URL of the post
     posts.description,
     -- Description of the post
-    posts.published_at -- Publication timestamp of the post
+    posts.published_at,
+    -- Publication timestamp of the post
+    posts.published_at_inferred,
+    -- Whether the publication timestamp is the first-seen time
+    posts.content,
+    -- Full content of the post
+    posts.authors,
+    -- Names of the post's authors
+    posts.categories -- Categories the post is filed under
 FROM posts
     INNER JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
-WHERE feed_follows.user_id = $1 -- Filter by the user ID
+WHERE feed_follows.user_id = sqlc.arg(user_id) -- Filter by the user ID
+    AND (
+        sqlc.narg(author)::TEXT IS NULL -- No author filter
+        OR EXISTS (
+            SELECT 1
+            FROM unnest(posts.authors) AS author(name)
+            WHERE lower(author.name) = lower(sqlc.narg(author))
+        )
+    )
+    AND (
+        sqlc.narg(category)::TEXT IS NULL -- No category filter
+        OR EXISTS (
+            SELECT 1
+            FROM unnest(posts.categories) AS category(name)
+            WHERE lower(category.name) = lower(sqlc.narg(category))
+        )
+    )
 ORDER BY posts.published_at DESC -- Order posts by publication date, most recent first
-LIMIT $2;
--- Limit the number of posts returned
\ No newline at end of file
+LIMIT sqlc.arg('limit');
+-- Limit the number of posts returned
+-- name: MovePosts :exec
+-- Move the posts of one feed to another, skipping posts the other feed already has
+UPDATE posts
+SET feed_id = sqlc.arg(to_feed_id),
+    -- Feed the posts move to
+    updated_at = CURRENT_TIMESTAMP -- Update the modified timestamp to now
+WHERE posts.feed_id = sqlc.arg(from_feed_id) -- Feed the posts move away from
+    AND posts.guid NOT IN (
+        SELECT existing.guid
+        FROM posts AS existing
+        WHERE existing.feed_id = sqlc.arg(to_feed_id)
+    );
@@ -0,0 +1,11 @@
+-- +goose Up
+-- Add HTTP cache validators so feeds can be fetched with conditional GET requests
+ALTER TABLE feeds
+ADD COLUMN etag TEXT DEFAULT NULL,
+    -- ETag header returned by the last successful fetch
+ADD COLUMN last_modified TEXT DEFAULT NULL;
+-- Last-Modified header returned by the last successful fetch
+-- +goose Down
+-- Remove the cache validator columns from the `feeds` table
+ALTER TABLE feeds DROP COLUMN etag,
+    DROP COLUMN last_modified;
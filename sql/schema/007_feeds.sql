@@ -0,0 +1,11 @@
+-- +goose Up
+-- Add per-feed scheduling so each feed is fetched at its own pace
+ALTER TABLE feeds
+ADD COLUMN next_fetch_at TIMESTAMP DEFAULT NULL,
+    -- When the feed is next due to be fetched (NULL means immediately)
+ADD COLUMN fetch_interval INTEGER NOT NULL DEFAULT 3600;
+-- Current interval between fetches, in seconds
+-- +goose Down
+-- Remove the scheduling columns from the `feeds` table
+ALTER TABLE feeds DROP COLUMN next_fetch_at,
+    DROP COLUMN fetch_interval;
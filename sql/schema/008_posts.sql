@@ -0,0 +1,22 @@
+-- +goose Up
+-- Identify posts by their feed item GUID instead of their URL
+ALTER TABLE posts
+ADD COLUMN guid TEXT;
+-- GUID of the item (falls back to its URL when the feed provides none)
+-- Existing posts were identified by URL, so use it as their GUID
+UPDATE posts
+SET guid = COALESCE(url, id::TEXT);
+ALTER TABLE posts
+ALTER COLUMN guid
+SET NOT NULL;
+-- URLs may now repeat across feeds and change between fetches
+ALTER TABLE posts DROP CONSTRAINT posts_url_key;
+-- Each GUID is unique within its feed
+ALTER TABLE posts
+ADD CONSTRAINT posts_feed_id_guid_key UNIQUE (feed_id, guid);
+-- +goose Down
+-- Restore URL-based identity and remove the `guid` column
+ALTER TABLE posts DROP CONSTRAINT posts_feed_id_guid_key;
+ALTER TABLE posts
+ADD CONSTRAINT posts_url_key UNIQUE (url);
+ALTER TABLE posts DROP COLUMN guid;
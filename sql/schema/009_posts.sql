@@ -0,0 +1,7 @@
+-- +goose Up
+-- Add the `content` column to store the full body of a post separately from its summary
+ALTER TABLE posts
+ADD COLUMN content TEXT DEFAULT NULL;
+-- +goose Down
+-- Remove the `content` column from the `posts` table
+ALTER TABLE posts DROP COLUMN content;
@@ -0,0 +1,30 @@
+-- +goose Up
+-- Create the `enclosures` table to store media files (podcast episodes, videos) attached to posts
+CREATE TABLE enclosures (
+    id UUID PRIMARY KEY,
+    -- Unique identifier for the enclosure
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    -- Creation timestamp
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    -- Last update timestamp
+    post_id UUID NOT NULL,
+    -- Foreign key linking to the `posts` table
+    url TEXT NOT NULL,
+    -- URL of the media file
+    mime_type TEXT,
+    -- Media type of the file (e.g., "audio/mpeg")
+    length BIGINT,
+    -- Size of the file in bytes (optional)
+    duration INTEGER,
+    -- Playing time in seconds (optional)
+    episode INTEGER,
+    -- Episode number (optional)
+    image_url TEXT,
+    -- URL of the episode artwork (optional)
+    CONSTRAINT post_fk FOREIGN KEY (post_id) REFERENCES posts (id) ON DELETE CASCADE,
+    -- Cascade delete on post removal
+    UNIQUE (post_id, url) -- Each file is stored once per post
+);
+-- +goose Down
+-- Drop the `enclosures` table and all its associated data
+DROP TABLE enclosures CASCADE;
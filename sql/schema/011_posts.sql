@@ -0,0 +1,11 @@
+-- +goose Up
+-- Add the authors and categories of each post
+ALTER TABLE posts
+ADD COLUMN authors TEXT [] NOT NULL DEFAULT '{}',
+    -- Names of the post's authors
+ADD COLUMN categories TEXT [] NOT NULL DEFAULT '{}';
+-- Categories (tags) the post is filed under
+-- +goose Down
+-- Remove the authors and categories columns from the `posts` table
+ALTER TABLE posts DROP COLUMN authors,
+    DROP COLUMN categories;
@@ -0,0 +1,23 @@
+-- +goose Up
+-- Add the metadata feeds publish about themselves
+ALTER TABLE feeds
+ADD COLUMN site_title TEXT,
+    -- Title the feed gives itself
+ADD COLUMN site_url TEXT,
+    -- URL of the website the feed belongs to
+ADD COLUMN site_description TEXT,
+    -- Description the feed gives itself
+ADD COLUMN language TEXT,
+    -- Language the feed is written in
+ADD COLUMN image_url TEXT,
+    -- URL of the feed's image, logo or icon
+ADD COLUMN generator TEXT;
+-- Software that generated the feed
+-- +goose Down
+-- Remove the metadata columns from the `feeds` table
+ALTER TABLE feeds DROP COLUMN site_title,
+    DROP COLUMN site_url,
+    DROP COLUMN site_description,
+    DROP COLUMN language,
+    DROP COLUMN image_url,
+    DROP COLUMN generator;
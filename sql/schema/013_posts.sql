@@ -0,0 +1,13 @@
+-- +goose Up
+-- Record whether a post's publication date was read from its feed or inferred
+ALTER TABLE posts
+ADD COLUMN published_at_inferred BOOLEAN NOT NULL DEFAULT FALSE;
+-- TRUE when the feed gave no usable date and the first-seen time is used instead
+-- Posts stored without a date fall back to when they were first seen
+UPDATE posts
+SET published_at = created_at,
+    published_at_inferred = TRUE
+WHERE published_at IS NULL;
+-- +goose Down
+-- Remove the inferred flag from the `posts` table
+ALTER TABLE posts DROP COLUMN published_at_inferred;
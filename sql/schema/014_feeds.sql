@@ -0,0 +1,17 @@
+-- +goose Up
+-- Track the outcome of each feed's fetches
+ALTER TABLE feeds
+ADD COLUMN last_status INTEGER,
+    -- HTTP status of the latest response (NULL when no response was received)
+ADD COLUMN last_error TEXT,
+    -- Error of the latest failed fetch
+ADD COLUMN last_error_at TIMESTAMP,
+    -- When the latest failed fetch happened
+ADD COLUMN consecutive_failures INTEGER NOT NULL DEFAULT 0;
+-- Number of failed fetches since the last successful one
+-- +goose Down
+-- Remove the fetch outcome columns from the `feeds` table
+ALTER TABLE feeds DROP COLUMN last_status,
+    DROP COLUMN last_error,
+    DROP COLUMN last_error_at,
+    DROP COLUMN consecutive_failures;
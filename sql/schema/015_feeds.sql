@@ -0,0 +1,14 @@
+-- +goose Up
+-- Track where each feed is in its lifecycle
+CREATE TYPE feed_status AS ENUM ('active', 'degraded', 'disabled');
+-- active: fetched normally; degraded: failing repeatedly; disabled: no longer fetched
+ALTER TABLE feeds
+ADD COLUMN status feed_status NOT NULL DEFAULT 'active',
+    -- Lifecycle status of the feed
+ADD COLUMN disabled_reason TEXT;
+-- Why the feed was disabled, if it is
+-- +goose Down
+-- Remove the lifecycle columns from the `feeds` table
+ALTER TABLE feeds DROP COLUMN status,
+    DROP COLUMN disabled_reason;
+DROP TYPE feed_status;
@@ -0,0 +1,18 @@
+-- +goose Up
+-- Create the `feed_url_history` table to remember the URLs feeds were at before they moved
+CREATE TABLE feed_url_history (
+    id UUID PRIMARY KEY,
+    -- Unique identifier for the history entry
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    -- Timestamp for when the feed moved away from the URL
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    -- Last updated timestamp
+    feed_id UUID NOT NULL,
+    -- Foreign key linking to the `feeds` table
+    url TEXT UNIQUE NOT NULL,
+    -- Previous URL of the feed
+    CONSTRAINT feed_fk FOREIGN KEY (feed_id) REFERENCES feeds (id) ON DELETE CASCADE -- Cascade delete on feed removal
+);
+-- +goose Down
+-- Drop the `feed_url_history` table and all associated data
+DROP TABLE feed_url_history CASCADE;
@@ -0,0 +1,20 @@
+-- +goose Up
+-- Create the `feed_credentials` table to store the encrypted credentials of private feeds
+CREATE TABLE feed_credentials (
+    id UUID PRIMARY KEY,
+    -- Unique identifier for the credentials
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    -- Creation timestamp
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    -- Last update timestamp
+    feed_id UUID UNIQUE NOT NULL,
+    -- Foreign key linking to the `feeds` table; each feed has at most one set of credentials
+    host TEXT NOT NULL,
+    -- Host the credentials may be sent to
+    secret BYTEA NOT NULL,
+    -- Credentials encrypted with the configured key (nonce followed by ciphertext)
+    CONSTRAINT feed_fk FOREIGN KEY (feed_id) REFERENCES feeds (id) ON DELETE CASCADE -- Cascade delete on feed removal
+);
+-- +goose Down
+-- Drop the `feed_credentials` table and all its associated data
+DROP TABLE feed_credentials CASCADE;
@@ -0,0 +1,12 @@
+-- +goose Up
+-- Track which feeds may still have posts keyed by their URL, as migration 008 left them
+ALTER TABLE feeds
+ADD COLUMN has_legacy_posts BOOLEAN NOT NULL DEFAULT TRUE;
+-- Whether the feed's posts still need rekeying to their GUIDs; true for every existing feed
+ALTER TABLE feeds
+ALTER COLUMN has_legacy_posts
+SET DEFAULT FALSE;
+-- Feeds added from now on only ever store posts keyed by their GUIDs
+-- +goose Down
+-- Remove the legacy posts flag from the `feeds` table
+ALTER TABLE feeds DROP COLUMN has_legacy_posts;